@@ -0,0 +1,75 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+// actions of responses and published messages sent by the pubsubsql server
+var _KNOWN_ACTIONS = map[string]bool{
+	"status":      true,
+	"select":      true,
+	"insert":      true,
+	"update":      true,
+	"delete":      true,
+	"key":         true,
+	"tag":         true,
+	"subscribe":   true,
+	"unsubscribe": true,
+	"add":         true,
+	"remove":      true,
+}
+
+//UnknownActionError reports a response or published message with an action the Client does not know.
+type UnknownActionError struct {
+	Action string
+}
+
+func (e *UnknownActionError) Error() string {
+	return "Unknown action: " + e.Action
+}
+
+//UnknownActionHandler is called with the action and the raw JSON message when the pubsubsql server
+//sends an action the Client does not know. The message slice must not be retained after the call returns.
+type UnknownActionHandler func(action string, message []byte)
+
+//RegisterAction adds the action to the actions known by the Client,
+//for applications handling actions added to the pubsubsql protocol.
+func (c *Client) RegisterAction(action string) {
+	if c.actions == nil {
+		c.actions = make(map[string]bool)
+	}
+	c.actions[action] = true
+}
+
+//KnownAction returns true if the action is sent by the pubsubsql server or was registered with RegisterAction.
+func (c *Client) KnownAction(action string) bool {
+	return _KNOWN_ACTIONS[action] || c.actions[action]
+}
+
+//OnUnknownAction installs the handler called for messages with an unknown action.
+//Without a handler an UnknownActionError is delivered to the Errors channel instead.
+//The message is processed normally after the handler returns.
+func (c *Client) OnUnknownAction(handler UnknownActionHandler) {
+	c.unknownAction = handler
+}
+
+// checkAction notices a decoded message with an unknown action,
+// error responses without an action are accepted
+func (c *Client) checkAction(message []byte) {
+	action := c.response.Action
+	if action == "" || c.KnownAction(action) {
+		return
+	}
+	if c.unknownAction != nil {
+		c.unknownAction(action, message)
+		return
+	}
+	c.report(&UnknownActionError{Action: action})
+}
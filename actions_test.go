@@ -0,0 +1,44 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+func (s *TestSuite) TestUnknownAction(c *C) {
+	client := newPipeClient(
+		`{"status":"ok","action":"status"}`,
+		`{"status":"ok","action":"merge"}`,
+		`{"status":"ok","action":"merge"}`,
+		`{"status":"ok","action":"truncate"}`,
+	)
+	c.Assert(client.Execute("status"), IsNil)
+	c.Assert(client.Execute("merge"), IsNil)
+	var err error
+	select {
+	case err = <-client.Errors():
+	default:
+	}
+	c.Assert(err, DeepEquals, &UnknownActionError{Action: "merge"})
+
+	var actions []string
+	client.OnUnknownAction(func(action string, message []byte) {
+		actions = append(actions, action+" "+string(message))
+	})
+	client.RegisterAction("merge")
+	c.Assert(client.KnownAction("merge"), Equals, true)
+	c.Assert(client.Execute("merge"), IsNil)
+	c.Assert(client.Execute("truncate"), IsNil)
+	c.Assert(client.Action(), Equals, "truncate")
+	c.Assert(actions, DeepEquals, []string{`truncate {"status":"ok","action":"truncate"}`})
+}
@@ -0,0 +1,109 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//BindArgs replaces the ? placeholders outside quoted values of the command with the arguments,
+//quoted and escaped for the pubsubsql grammar by QuoteValue.
+//Arguments may be strings, byte slices, integers, floating point numbers, booleans, times formatted
+//in the RFC 3339 format, durations, fmt.Stringer values and nil for an empty value.
+func BindArgs(command string, args ...interface{}) (string, error) {
+	var bound strings.Builder
+	quoted := false
+	next := 0
+	for i := 0; i < len(command); i++ {
+		ch := command[i]
+		switch {
+		case ch == '\'':
+			quoted = !quoted
+		case ch == '?' && !quoted:
+			if next >= len(args) {
+				return "", fmt.Errorf("Missing argument for placeholder %d", next+1)
+			}
+			value, err := formatArg(args[next])
+			if err != nil {
+				return "", fmt.Errorf("Argument %d: %v", next+1, err)
+			}
+			bound.WriteString(quoteValue(value))
+			next++
+			continue
+		}
+		bound.WriteByte(ch)
+	}
+	if next != len(args) {
+		return "", fmt.Errorf("%d arguments for %d placeholders", len(args), next)
+	}
+	return bound.String(), nil
+}
+
+//ExecuteArgs executes the command after replacing its ? placeholders with the arguments by BindArgs,
+//so values never need to be quoted by hand:
+//
+//	client.ExecuteArgs("insert into stocks (ticker, bid) values (?, ?)", "O'Neil", 140.5)
+func (c *Client) ExecuteArgs(command string, args ...interface{}) error {
+	bound, err := BindArgs(command, args...)
+	if err != nil {
+		return err
+	}
+	return c.Execute(bound)
+}
+
+// formatArg converts an argument to the string stored by the pubsubsql server
+func formatArg(arg interface{}) (string, error) {
+	switch v := arg.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int8:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int16:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int32:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case uint:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint8:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint16:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint32:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint64:
+		return strconv.FormatUint(v, 10), nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32), nil
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	case time.Duration:
+		return v.String(), nil
+	case fmt.Stringer:
+		return v.String(), nil
+	}
+	return "", fmt.Errorf("Unsupported type %T", arg)
+}
@@ -0,0 +1,51 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	. "gopkg.in/check.v1"
+	"time"
+)
+
+func (s *TestSuite) TestBindArgs(c *C) {
+	command, err := BindArgs("insert into stocks (ticker, bid, qty, open, at, note) values (?, ?, ?, ?, ?, ?)",
+		"O'Neil", 140.5, uint16(3), true, time.Date(2014, 3, 1, 10, 0, 0, 0, time.UTC), nil)
+	c.Assert(err, IsNil)
+	c.Assert(command, Equals, "insert into stocks (ticker, bid, qty, open, at, note) values ('O''Neil', 140.5, 3, true, '2014-03-01T10:00:00Z', '')")
+
+	command, err = BindArgs("select * from stocks where note = 'why?' and ticker = ?", "IBM")
+	c.Assert(err, IsNil)
+	c.Assert(command, Equals, "select * from stocks where note = 'why?' and ticker = IBM")
+
+	// an injected clause stays inside the quoted value
+	command, err = BindArgs("delete from stocks where ticker = ?", "IBM' or ticker = 'MSFT")
+	c.Assert(err, IsNil)
+	c.Assert(command, Equals, "delete from stocks where ticker = 'IBM'' or ticker = ''MSFT'")
+
+	_, err = BindArgs("select * from stocks where ticker = ?")
+	c.Assert(err, ErrorMatches, "Missing argument for placeholder 1")
+	_, err = BindArgs("select * from stocks", 1)
+	c.Assert(err, ErrorMatches, "1 arguments for 0 placeholders")
+	_, err = BindArgs("select * from stocks where ticker = ?", []string{"IBM"})
+	c.Assert(err, ErrorMatches, `Argument 1: Unsupported type \[\]string`)
+}
+
+func (s *TestSuite) TestExecuteArgs(c *C) {
+	client, requests := newCaptureClient()
+	c.Assert(client.ExecuteArgs("update stocks set bid = ?", 1, 2), NotNil)
+	done := make(chan error, 1)
+	go func() { done <- client.ExecuteArgs("update stocks set bid = ? where ticker = ?", 141, "IBM") }()
+	c.Assert(<-requests, Equals, "update stocks set bid = 141 where ticker = IBM")
+	// the capture server never answers
+	client.interrupt()
+	c.Assert(<-done, NotNil)
+}
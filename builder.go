@@ -0,0 +1,78 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"bytes"
+	"sync"
+	"sync/atomic"
+)
+
+// buffers larger than this are not returned to the pool
+var _MAX_POOLED_BUILDER = 64 * 1024
+
+//BuilderStats is a snapshot of the statistics of the buffer pool used to compose commands.
+type BuilderStats struct {
+	//Gets is the number of buffers taken from the pool.
+	Gets uint64
+	//Allocations is the number of buffers allocated because the pool was empty.
+	Allocations uint64
+	//AverageSize is the moving average of the composed command sizes in bytes,
+	//new buffers are preallocated to it.
+	AverageSize int
+}
+
+// builderPool recycles the buffers of the command builders
+type builderPool struct {
+	// 64 bit counters first for atomic access on 32 bit platforms
+	gets        uint64
+	allocations uint64
+	average     int64
+	pool        sync.Pool
+}
+
+var _BUILDERS builderPool
+
+func (this *builderPool) get() *bytes.Buffer {
+	atomic.AddUint64(&this.gets, 1)
+	buffer, _ := this.pool.Get().(*bytes.Buffer)
+	if buffer == nil {
+		atomic.AddUint64(&this.allocations, 1)
+		buffer = new(bytes.Buffer)
+	}
+	if average := int(atomic.LoadInt64(&this.average)); buffer.Cap() < average {
+		buffer.Grow(average)
+	}
+	return buffer
+}
+
+func (this *builderPool) put(buffer *bytes.Buffer) {
+	size := int64(buffer.Len())
+	average := atomic.LoadInt64(&this.average)
+	// a lost update under contention only delays the average
+	atomic.StoreInt64(&this.average, average+(size-average)/8)
+	if buffer.Cap() > _MAX_POOLED_BUILDER {
+		return
+	}
+	buffer.Reset()
+	this.pool.Put(buffer)
+}
+
+//CommandBuilderStats returns the statistics of the buffer pool used by the command builders
+//such as InsertCommands, for tuning allocation-sensitive producers.
+func CommandBuilderStats() BuilderStats {
+	return BuilderStats{
+		Gets:        atomic.LoadUint64(&_BUILDERS.gets),
+		Allocations: atomic.LoadUint64(&_BUILDERS.allocations),
+		AverageSize: int(atomic.LoadInt64(&_BUILDERS.average)),
+	}
+}
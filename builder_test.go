@@ -0,0 +1,43 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	. "gopkg.in/check.v1"
+	"strings"
+)
+
+func (s *TestSuite) TestBuilderPool(c *C) {
+	var pool builderPool
+	buffer := pool.get()
+	buffer.WriteString(strings.Repeat("x", 800))
+	pool.put(buffer)
+	c.Assert(pool.average, Equals, int64(100))
+	buffer = pool.get()
+	c.Assert(buffer.Len(), Equals, 0)
+	c.Assert(buffer.Cap() >= 100, Equals, true)
+	c.Assert(pool.gets, Equals, uint64(2))
+
+	// oversized buffers are dropped
+	buffer.WriteString(strings.Repeat("x", _MAX_POOLED_BUILDER+1))
+	pool.put(buffer)
+	c.Assert(pool.get().Cap() <= _MAX_POOLED_BUILDER, Equals, true)
+}
+
+func (s *TestSuite) TestCommandBuilderStats(c *C) {
+	before := CommandBuilderStats()
+	_, err := InsertCommands("stocks", []string{"ticker"}, [][]string{{"IBM"}})
+	c.Assert(err, IsNil)
+	after := CommandBuilderStats()
+	c.Assert(after.Gets-before.Gets, Equals, uint64(1))
+	c.Assert(after.AverageSize > 0, Equals, true)
+}
@@ -0,0 +1,87 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"time"
+)
+
+// cacheEntry holds a copy of the JSON response for a cached command
+type cacheEntry struct {
+	json    []byte
+	expires time.Time
+}
+
+// commands that are never answered from the cache besides the mutating ones
+var _UNCACHEABLE_COMMANDS = map[string]bool{
+	"stream":      true,
+	"subscribe":   true,
+	"unsubscribe": true,
+}
+
+//ExecuteCached executes an idempotent command, such as a schema query, and caches the response for ttl.
+//While the cached response is fresh it is returned without a round trip to the pubsubsql server.
+//Only responses that fit in a single batch are cached.
+//Mutating and subscription commands are always sent to the pubsubsql server and never cached.
+func (c *Client) ExecuteCached(command string, ttl time.Duration) error {
+	verb := commandVerb(command)
+	if _MUTATING_COMMANDS[verb] || _UNCACHEABLE_COMMANDS[verb] {
+		return c.Execute(command)
+	}
+	err := c.checkCommand(command)
+	if err != nil {
+		return err
+	}
+	// key on the command actually sent so tenants do not share entries
+	key := prefixTable(command, c.tablePrefix)
+	now := _NOW()
+	if entry, ok := c.cache[key]; ok {
+		if now.Before(entry.expires) {
+			c.reset()
+			return c.unmarshalJSON(entry.json)
+		}
+		delete(c.cache, key)
+	}
+	err = c.Execute(command)
+	if err != nil {
+		return err
+	}
+	if c.response.Rows == c.response.Torow {
+		if c.cache == nil {
+			c.cache = make(map[string]cacheEntry)
+		}
+		c.removeExpired(now)
+		// raw json is backed by the read buffer, copy it
+		t := make([]byte, len(c.rawjson))
+		copy(t, c.rawjson)
+		c.cache[key] = cacheEntry{json: t, expires: now.Add(ttl)}
+	}
+	return nil
+}
+
+func (c *Client) removeExpired(now time.Time) {
+	for command, entry := range c.cache {
+		if !now.Before(entry.expires) {
+			delete(c.cache, command)
+		}
+	}
+}
+
+//InvalidateCache removes the cached response for the command.
+//When command is empty all cached responses are removed.
+func (c *Client) InvalidateCache(command string) {
+	if command == "" {
+		c.cache = nil
+		return
+	}
+	delete(c.cache, prefixTable(command, c.tablePrefix))
+}
@@ -0,0 +1,67 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	. "gopkg.in/check.v1"
+	"time"
+)
+
+func (s *TestSuite) TestExecuteCached(c *C) {
+	client := newPipeClient(`{"status":"ok","action":"status"}`)
+	c.Assert(client.ExecuteCached("status", time.Minute), IsNil)
+	// the server is gone, the second call must be served from the cache
+	c.Assert(client.ExecuteCached("status", time.Minute), IsNil)
+	c.Assert(client.Action(), Equals, "status")
+
+	client.InvalidateCache("status")
+	c.Assert(client.ExecuteCached("status", time.Minute), NotNil)
+}
+
+func (s *TestSuite) TestExecuteCachedSkipsMutating(c *C) {
+	client := newPipeClient(
+		`{"status":"ok","action":"insert"}`,
+		`{"status":"ok","action":"insert"}`,
+	)
+	c.Assert(client.ExecuteCached("insert into stocks (ticker) values (IBM)", time.Minute), IsNil)
+	c.Assert(client.cache, HasLen, 0)
+	c.Assert(client.ExecuteCached("insert into stocks (ticker) values (IBM)", time.Minute), IsNil)
+}
+
+func (s *TestSuite) TestExecuteCachedChecksCommand(c *C) {
+	client := newPipeClient(`{"status":"ok","action":"status"}`)
+	c.Assert(client.ExecuteCached("status", time.Minute), IsNil)
+	policy, err := DenyPatterns(`^status$`)
+	c.Assert(err, IsNil)
+	client.SetCommandPolicy(policy)
+	c.Assert(client.ExecuteCached("status", time.Minute), FitsTypeOf, &PolicyError{})
+}
+
+func (s *TestSuite) TestExecuteCachedRemovesExpired(c *C) {
+	client := newPipeClient(
+		`{"status":"ok","action":"status"}`,
+		`{"status":"ok","action":"select"}`,
+	)
+	c.Assert(client.ExecuteCached("status", time.Nanosecond), IsNil)
+	time.Sleep(time.Millisecond)
+	c.Assert(client.ExecuteCached("select * from stocks", time.Minute), IsNil)
+	c.Assert(client.cache, HasLen, 1)
+}
+
+func (s *TestSuite) TestExecuteCachedTablePrefix(c *C) {
+	client := newPipeClient(`{"status":"ok","action":"select"}`)
+	client.SetTablePrefix("tenantA_")
+	c.Assert(client.ExecuteCached("select * from orders", time.Minute), IsNil)
+	client.SetTablePrefix("tenantB_")
+	// tenantB must not be served tenantA rows, the server is gone so the call fails
+	c.Assert(client.ExecuteCached("select * from orders", time.Minute), NotNil)
+}
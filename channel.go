@@ -0,0 +1,115 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"fmt"
+)
+
+// capacity of the channels returned by SubscribeChan
+const _MESSAGE_CHANNEL_SIZE = 64
+
+// milliseconds the delivery goroutine waits before it lets SubscribeChan execute its command
+const _DELIVERY_SLICE = 100
+
+//Message is a message published by the pubsubsql server, with all its rows.
+type Message struct {
+	ResultSet
+	PubSubId string
+	Table    string
+	//Payload is the original JSON of the message, of its last batch when the rows came in several.
+	Payload []byte
+}
+
+//SubscribeChan executes the subscribe command and delivers its published messages on the returned channel.
+//A goroutine started by the first call reads the messages of all such subscriptions,
+//polling the connection and waiting while a channel is full; it is not a separate reader.
+//From then on the Client belongs to that goroutine until all channels are closed:
+//other goroutines may only call Stream, StreamBatch, SubscribeChan and the Unsubscribe, Pause,
+//Resume and Resubscribe methods of a Subscription, which wait for the goroutine to release the Client.
+//Execute, Query, WaitForPubSub, NextRow and the other methods reading responses must not be called.
+//A channel is closed when its subscription is removed and all channels are closed when the connection is.
+func (c *Client) SubscribeChan(command string) (<-chan Message, error) {
+	if commandVerb(command) != "subscribe" {
+		return nil, fmt.Errorf("Not a subscribe command: %q", command)
+	}
+	c.pmu.Lock()
+	defer c.pmu.Unlock()
+	err := c.Execute(command)
+	if err != nil {
+		return nil, err
+	}
+	s := c.Subscription(c.response.PubSubId)
+	if s == nil {
+		return nil, fmt.Errorf("%w missing pubsubid", ErrProtocol)
+	}
+	ch := make(chan Message, _MESSAGE_CHANNEL_SIZE)
+	if c.channels == nil {
+		c.channels = make(map[*Subscription]chan Message)
+	}
+	c.channels[s] = ch
+	if !c.delivering {
+		c.delivering = true
+		go c.deliver()
+	}
+	return ch, nil
+}
+
+// deliver reads published messages and sends them on the channels of their subscriptions
+func (c *Client) deliver() {
+	for {
+		c.pmu.Lock()
+		c.closeChannels(false)
+		if len(c.channels) == 0 {
+			c.delivering = false
+			c.pmu.Unlock()
+			return
+		}
+		var message Message
+		var ch chan Message
+		err := c.WaitForPubSub(_DELIVERY_SLICE)
+		if err == nil {
+			message, err = c.message()
+			if err == nil {
+				ch = c.channels[c.subscriptions[message.PubSubId]]
+			}
+		}
+		if err != nil && err != ErrTimeout && !c.Connected() {
+			c.closeChannels(true)
+		}
+		c.pmu.Unlock()
+		if ch != nil {
+			ch <- message
+		}
+	}
+}
+
+// message reads the current published message with all its rows
+func (c *Client) message() (Message, error) {
+	pubsubid, table := c.response.PubSubId, c.PubSubTable()
+	result, err := c.readResultSet()
+	if err != nil {
+		return Message{}, err
+	}
+	payload := append([]byte(nil), c.rawjson...)
+	return Message{ResultSet: *result, PubSubId: pubsubid, Table: table, Payload: payload}, nil
+}
+
+// closeChannels closes the channels of removed subscriptions or all channels
+func (c *Client) closeChannels(all bool) {
+	for s, ch := range c.channels {
+		if all || (c.subscriptions[s.pubsubId] != s && !s.paused) {
+			close(ch)
+			delete(c.channels, s)
+		}
+	}
+}
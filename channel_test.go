@@ -0,0 +1,81 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	. "gopkg.in/check.v1"
+	"time"
+)
+
+func (s *TestSuite) TestSubscribeChan(c *C) {
+	client := newPipeClient(
+		`{"status":"ok","action":"subscribe","pubsubid":"1"}`,
+		`pubsub:{"status":"ok","action":"add","pubsubid":"1","rows":2,"fromrow":1,"torow":2,"columns":["id","ticker"],"data":[["1","IBM"],["2","MSFT"]]}`,
+		`pubsub:{"status":"ok","action":"update","pubsubid":"1","rows":1,"fromrow":1,"torow":1,"columns":["id","bid"],"data":[["1","140"]]}`,
+	)
+	_, err := client.SubscribeChan("select * from stocks")
+	c.Assert(err, ErrorMatches, "Not a subscribe command.*")
+	messages, err := client.SubscribeChan("subscribe * from stocks")
+	c.Assert(err, IsNil)
+
+	message := receiveMessage(c, messages)
+	c.Assert(message.Action, Equals, "add")
+	c.Assert(message.PubSubId, Equals, "1")
+	c.Assert(message.Table, Equals, "stocks")
+	c.Assert(message.Rows, HasLen, 2)
+	c.Assert(message.Value(1, "ticker"), Equals, "MSFT")
+
+	message = receiveMessage(c, messages)
+	c.Assert(message.Action, Equals, "update")
+	c.Assert(message.Value(0, "bid"), Equals, "140")
+
+	// the pipe server closes the connection after its script
+	select {
+	case _, ok := <-messages:
+		c.Assert(ok, Equals, false)
+	case <-time.After(5 * time.Second):
+		c.Fatal("channel was not closed")
+	}
+}
+
+func (s *TestSuite) TestSubscribeChanUnsubscribe(c *C) {
+	address := serveConnections(c, []string{
+		`{"status":"ok","action":"subscribe","pubsubid":"1"}`,
+		`{"status":"ok","action":"unsubscribe"}`,
+		`{"status":"ok","action":"status"}`,
+	})
+	client := new(Client)
+	c.Assert(client.Connect(address), IsNil)
+	messages, err := client.SubscribeChan("subscribe * from stocks")
+	c.Assert(err, IsNil)
+	// the delivery goroutine polls the connection meanwhile
+	time.Sleep(10 * time.Millisecond)
+	c.Assert(client.Subscription("1").Unsubscribe(), IsNil)
+	select {
+	case _, ok := <-messages:
+		c.Assert(ok, Equals, false)
+	case <-time.After(5 * time.Second):
+		c.Fatal("channel was not closed")
+	}
+	c.Assert(client.Execute("status"), IsNil)
+}
+
+func receiveMessage(c *C, messages <-chan Message) Message {
+	select {
+	case message, ok := <-messages:
+		c.Assert(ok, Equals, true)
+		return message
+	case <-time.After(5 * time.Second):
+		c.Fatal("no message delivered")
+	}
+	return Message{}
+}
@@ -13,16 +13,24 @@ package pubsubsql
 
 import (
 	"container/list"
+	"context"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
 	"net"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 var _CLIENT_DEFAULT_BUFFER_SIZE int = 2048
 
+//ErrWriteTimeout is returned when a command could not be written within the write timeout.
+//The connection is closed like after any write error since a partially written command leaves it unusable.
+var ErrWriteTimeout = errors.New("Write timed out")
+
 // respnoseData holds unmarshaled result from pubsubsql JSON response
 type responseData struct {
 	Status   string
@@ -37,6 +45,53 @@ type responseData struct {
 	Data     [][]string
 }
 
+// responseAliases holds alternative names of response fields,
+// names differing only in case are matched by encoding/json already
+type responseAliases struct {
+	RowCount      *int    `json:"rowCount"`
+	RowCountSnake *int    `json:"row_count"`
+	Message       *string `json:"message"`
+	PubSubIdSnake *string `json:"pubsub_id"`
+	FromrowSnake  *int    `json:"from_row"`
+	TorowSnake    *int    `json:"to_row"`
+}
+
+//UnmarshalJSON decodes the response accepting the aliases of renamed fields,
+//the canonical field wins when both are present.
+func (c *responseData) UnmarshalJSON(bytes []byte) error {
+	type fields responseData
+	aux := struct {
+		*fields
+		responseAliases
+	}{fields: (*fields)(c)}
+	err := json.Unmarshal(bytes, &aux)
+	if err != nil {
+		return err
+	}
+	aliasInt(&c.Rows, aux.RowCount, aux.RowCountSnake)
+	aliasInt(&c.Fromrow, aux.FromrowSnake)
+	aliasInt(&c.Torow, aux.TorowSnake)
+	aliasString(&c.Msg, aux.Message)
+	aliasString(&c.PubSubId, aux.PubSubIdSnake)
+	return nil
+}
+
+func aliasInt(field *int, aliases ...*int) {
+	for _, alias := range aliases {
+		if *field == 0 && alias != nil {
+			*field = *alias
+		}
+	}
+}
+
+func aliasString(field *string, aliases ...*string) {
+	for _, alias := range aliases {
+		if *field == "" && alias != nil {
+			*field = *alias
+		}
+	}
+}
+
 func (c *responseData) reset() {
 	c.Status = ""
 	c.Msg = ""
@@ -50,58 +105,295 @@ func (c *responseData) reset() {
 	c.Id = ""
 }
 
+//Client is a connection to the pubsubsql server.
+//
+//Stream, StreamBatch and Query may be called from multiple goroutines.
+//Execute, NextRow, WaitForPubSub and the methods reading the current response keep their state
+//in the Client, so they must be used by one goroutine at a time, which must not use them while Query runs;
+//SetDebug detects such overlapping calls. Settings are changed before the Client is shared.
+//To consume published messages while other goroutines query, use separate Clients.
 type Client struct {
 	address   string
+	resolver  Resolver
+	dialer    Dialer
 	rw        netHelper
 	requestId uint32
+	commandId uint32
 	rawjson   []byte
+	// encrypts the connections when set
+	tlsConfig *tls.Config
+	// settings of NewClient, zero for the defaults
+	bufferSize     int
+	connectTimeout time.Duration
+	logger         *log.Logger
+	// dedicated subscription connection in dual connection mode
+	dual  bool
+	subrw netHelper
+	// connection of the current response
+	current *netHelper
+	// serializes concurrent Query calls
+	qmu sync.Mutex
+	// channels of SubscribeChan, guarded by pmu
+	pmu        sync.Mutex
+	channels   map[*Subscription]chan Message
+	delivering bool
+	// serializes writes from concurrent Stream calls
+	wmu          sync.Mutex
+	writeTimeout time.Duration
+	pacing       writePacing
+	logLevel     LogLevel
+	// options of UpdateOptions not yet applied by the reading goroutine
+	omu     sync.Mutex
+	pending *RuntimeOptions
+	// store and forward buffer for streamed commands
+	outbox *outbox
+	// dedup keys added to streamed commands
+	dedupColumn   string
+	dedupPrefix   string
+	dedupSequence uint64
+	// protocol event hooks
+	observer Observer
+	// random sample of logged payloads
+	sampler *payloadSampler
+	// correlation tag of the written commands
+	tag string
+	// redaction rules by column for logged and kept payloads, a map[string]Redactor
+	redactions atomic.Value
+	// client side row filter
+	filter RowFilter
+	// size limits of the decoded values
+	limits DecodeLimits
+	// automatic reconnection after failures
+	reconnectOpts *ReconnectOptions
+	reconnecting  bool
+	// actions registered in addition to the known ones
+	actions       map[string]bool
+	unknownAction UnknownActionHandler
+	// copies of the last raw responses, guarded by smu
+	history *history
+	// message metrics
+	smu    sync.Mutex
+	stats  ClientStats
+	tables map[string]*TableStats
+	// observed interval between frames
+	cadence cadence
+	// connection state notifications
+	stateHandler StateHandler
+	closeReason  CloseReason
+	// asynchronous failures
+	errs     chan error
+	errsOnce sync.Once
+	// misuse detection
+	debug  bool
+	busy   int32
+	failed bool
 	//
 	response responseData
 	record   int
 	columns  map[string]int
 
 	// pubsub back log
-	backlog list.List
+	backlog    list.List
+	maxBacklog int
+	// closed by ResumeIntake, nil while the intake runs
+	imu     sync.Mutex
+	resumed chan struct{}
+	// cached responses of idempotent commands
+	cache map[string]cacheEntry
+	// reject mutating commands
+	readOnly bool
+	// veto outgoing commands
+	policy CommandPolicy
+	// tenant namespace prepended to table names
+	tablePrefix string
+	// key columns by table for the by-key helpers
+	keyColumns map[string]string
+	// active subscriptions by pubsubid
+	subscriptions map[string]*Subscription
+	// envelope of the current response
+	responseId    uint32
+	batch         int
+	responseTable string
 }
 
 //Connect connects the Client to the pubsubsql server.
 //Address string has the form host:port.
 func (c *Client) Connect(address string) error {
+	return c.ConnectContext(context.Background(), address)
+}
+
+//ConnectContext connects the Client to the pubsubsql server like Connect,
+//giving up when ctx is done before the connection is established.
+func (c *Client) ConnectContext(ctx context.Context, address string) error {
 	c.address = address
 	c.Disconnect()
-	conn, err := net.DialTimeout("tcp", c.address, time.Millisecond*1000)
+	return c.open(ctx)
+}
+
+// open connects to the address of the Client
+func (c *Client) open(ctx context.Context) error {
+	dial, err := c.resolve(c.address)
 	if err != nil {
 		return err
 	}
-	c.rw.set(conn, _CLIENT_DEFAULT_BUFFER_SIZE)
+	conn, err := c.dial(ctx, dial)
+	if err != nil {
+		return err
+	}
+	var subconn net.Conn
+	if c.dual {
+		subconn, err = c.dial(ctx, dial)
+		if err != nil {
+			conn.Close()
+			return err
+		}
+	}
+	c.attach(conn, subconn)
+	return c.flushOutbox()
+}
 
-	return nil
+// attach makes the Client use the connections, subconn is nil unless in dual connection mode
+func (c *Client) attach(conn net.Conn, subconn net.Conn) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	c.rw.set(conn, c.readBufferSize())
+	if subconn != nil {
+		c.subrw.set(subconn, c.readBufferSize())
+	}
+	c.applyWriteModeLocked()
+	c.closeReason = CloseNone
+	if c.stateHandler != nil {
+		c.stateHandler(true, CloseNone)
+	}
 }
 
 //Disconnect disconnects the Client from the pubsubsql server.
 func (c *Client) Disconnect() {
-	c.write("close")
-	// write may generate error so we reset after instead
+	c.wmu.Lock()
+	for _, rw := range []*netHelper{&c.rw, &c.subrw} {
+		if rw.valid() {
+			c.requestId++
+			// the connection is closed regardless of the outcome
+			rw.writeHeaderAndMessageTimeout(c.requestId, []byte("close"), c.writeTimeout)
+		}
+	}
+	c.closeLocked(CloseUser)
+	c.wmu.Unlock()
 	c.reset()
-	c.rw.close()
+	c.subscriptions = nil
+}
+
+//SetReadOnly enables or disables the read-only mode.
+//In read-only mode the Client rejects mutating commands (insert, update, delete, key and tag)
+//with ErrReadOnly before they are sent to the pubsubsql server.
+func (c *Client) SetReadOnly(readOnly bool) {
+	c.readOnly = readOnly
+}
+
+//ReadOnly returns true if the Client is in read-only mode.
+func (c *Client) ReadOnly() bool {
+	return c.readOnly
+}
+
+//SetTablePrefix sets a namespace that is prepended to the table name of every command,
+//so tenants sharing one pubsubsql server only see their own tables.
+//For example with prefix "tenantA_" the command "select * from orders" selects from tenantA_orders.
+func (c *Client) SetTablePrefix(prefix string) {
+	c.tablePrefix = prefix
+}
+
+//SetConnectTimeout sets the maximum time to establish a connection to the pubsubsql server.
+//Zero restores the default of 1 second.
+func (c *Client) SetConnectTimeout(timeout time.Duration) {
+	c.connectTimeout = timeout
+}
+
+//SetWriteTimeout sets the maximum time a command may take to be written to the connection.
+//When the pubsubsql server stops reading, writes fail with ErrWriteTimeout instead of blocking.
+//Zero, the default, disables the timeout.
+func (c *Client) SetWriteTimeout(timeout time.Duration) {
+	c.writeTimeout = timeout
+}
+
+//SetBufferShrinkAfter makes the Client release a read buffer that grew for a large response
+//once no large response was read for the given duration. Zero, the default, keeps the buffer.
+func (c *Client) SetBufferShrinkAfter(idle time.Duration) {
+	c.rw.shrinkAfter = idle
+	c.subrw.shrinkAfter = idle
+}
+
+//BufferHighWater returns the size in bytes of the largest read buffer allocated by the Client.
+func (c *Client) BufferHighWater() int {
+	if c.subrw.highWater > c.rw.highWater {
+		return c.subrw.highWater
+	}
+	return c.rw.highWater
+}
+
+//SetDualConnection makes the next Connect open two connections to the pubsubsql server,
+//one for subscribe and unsubscribe commands and the published messages, the other for all other commands,
+//so reading a large result set never delays published messages on the pubsubsql server.
+//The connections are opened and closed together.
+func (c *Client) SetDualConnection(dual bool) {
+	c.dual = dual
+}
+
+// connection returns the connection for subscription traffic or for other commands
+func (c *Client) connection(subscription bool) *netHelper {
+	if subscription && c.dual {
+		return &c.subrw
+	}
+	return &c.rw
 }
 
 //Connected returns true if the Client is currently connected to the pubsubsql server.
 func (c *Client) Connected() bool {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
 	return c.rw.valid()
 }
 
-//Execute executes a command against the pubsubsql server and returns true on success.
+//Execute executes a command against the pubsubsql server and returns an error on failure.
 //The pubsubsql server returns to the Client a response in JSON format.
 func (c *Client) Execute(command string) error {
+	return c.executeContext(context.Background(), command)
+}
+
+// executeContext executes the command, ctx only bounds the automatic reconnection
+func (c *Client) executeContext(ctx context.Context, command string) error {
+	c.enter("Execute")
+	defer c.leave()
+	err := c.reconnect(ctx)
+	if err == nil {
+		err = c.execute(command)
+	}
+	c.failed = err != nil
+	if err != nil {
+		return err
+	}
+	c.trackSubscriptions(command)
+	c.trackKeys(command)
+	return nil
+}
+
+func (c *Client) execute(command string) error {
 	c.reset()
-	err := c.write(command)
+	err := c.checkCommand(command)
+	if err != nil {
+		return err
+	}
+	verb := commandVerb(command)
+	c.current = c.connection(verb == "subscribe" || verb == "unsubscribe")
+	message := prefixTable(command, c.tablePrefix)
+	c.commandId, err = c.write(c.current, message)
 	if err != nil {
 		return err
 	}
+	c.responseTable, _, _ = commandTable(command)
+	c.recordSent(c.responseTable, len(message))
 
 	var bytes []byte
-	var header *netHeader
+	var header *Header
 	for {
 		c.reset()
 		header, bytes, err = c.read()
@@ -109,8 +401,9 @@ func (c *Client) Execute(command string) error {
 			break
 		}
 
-		if header.RequestId == c.requestId {
+		if header.RequestId == c.commandId {
 			// response we are waiting for
+			c.setEnvelope(header.RequestId, 0)
 			return c.unmarshalJSON(bytes)
 		} else if header.RequestId == 0 {
 			// pubsub action, save it and skip it for now
@@ -118,26 +411,130 @@ func (c *Client) Execute(command string) error {
 			//WE MUST COPY BYTES SINCE THEY ARE REUSED IN NetHelper
 			t := make([]byte, header.MessageSize, header.MessageSize)
 			copy(t, bytes[0:header.MessageSize])
-			c.backlog.PushBack(t)
-		} else if header.RequestId < c.requestId {
+			c.pushBacklog(backlogMessage{bytes: t, received: _NOW()})
+		} else if header.RequestId < c.commandId {
 			// we did not read full result set from previous command ignore it or report error?
 			// for now lets ignore it, continue reading until we hit our request id
 			c.reset()
 		} else {
 			// c should never happen
-			return errors.New("protocol error invalid requestId")
+			c.closeWith(CloseProtocol)
+			return fmt.Errorf("%w invalid requestId", ErrProtocol)
 		}
 	}
 
 	return err
 }
 
-//Stream sends a command to the pubsubsql server and returns true on success.
+//Stream sends a command to the pubsubsql server and returns an error on failure.
 //The pubsubsql server does not return a response to the Client.
+//Stream is safe to call from multiple goroutines, also while another goroutine uses the Client;
+//it does not change the response of the last executed command.
 func (c *Client) Stream(command string) error {
-	c.reset()
+	err := c.checkCommand(command)
+	if err != nil {
+		return err
+	}
 	//TODO optimize
-	return c.write("stream " + command)
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	return c.streamTableLocked(command)
+}
+
+//StreamBatch sends the commands to the pubsubsql server like Stream, holding the connection
+//for the whole batch so commands streamed by other goroutines are not interleaved.
+//No command is sent when any of them is rejected by the Client settings.
+func (c *Client) StreamBatch(commands []string) error {
+	for _, command := range commands {
+		err := c.checkCommand(command)
+		if err != nil {
+			return err
+		}
+	}
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	for _, command := range commands {
+		err := c.streamTableLocked(command)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// streamTableLocked streams the command and counts it for its table
+func (c *Client) streamTableLocked(command string) error {
+	message := []byte("stream " + prefixTable(c.dedupCommand(command), c.tablePrefix))
+	err := c.streamLocked(message)
+	if err == nil {
+		table, _, _ := commandTable(command)
+		c.recordSent(table, len(message))
+	}
+	return err
+}
+
+//ErrNotConfirmed is returned by StreamConfirmed when the confirmation select returns no rows.
+var ErrNotConfirmed = errors.New("Streamed command was not confirmed")
+
+//StreamConfirmed streams the command and then executes the confirm select, returning
+//ErrNotConfirmed when the select returns no rows. Since the pubsubsql server processes the
+//commands of a connection in order, the select observes the effect of the streamed command.
+//When confirm is empty a status command is executed instead, which only confirms
+//that the pubsubsql server received the streamed command.
+//The response of the confirmation is available through the result set methods.
+func (c *Client) StreamConfirmed(command string, confirm string) error {
+	if confirm != "" && commandVerb(confirm) != "select" {
+		return fmt.Errorf("Confirmation must be a select command: %q", confirm)
+	}
+	err := c.Stream(command)
+	if err != nil {
+		return err
+	}
+	if confirm == "" {
+		return c.Execute("status")
+	}
+	err = c.Execute(confirm)
+	if err != nil {
+		return err
+	}
+	if c.response.Rows == 0 {
+		return ErrNotConfirmed
+	}
+	return nil
+}
+
+// streamLocked writes the message or buffers it when store and forward is enabled
+func (c *Client) streamLocked(message []byte) error {
+	// the stream prefix and the dedup key were added after checkCommand
+	if err := checkSize(len(message)); err != nil {
+		return err
+	}
+	if c.outbox == nil {
+		_, err := c.writeLocked(message)
+		return err
+	}
+	if c.rw.valid() {
+		_, err := c.writeLocked(message)
+		if err == nil {
+			return nil
+		}
+		// the connection is closed, buffer until the next Connect
+		c.report(err)
+	}
+	return c.outbox.push(message)
+}
+
+// flushOutbox sends the commands buffered while disconnected
+func (c *Client) flushOutbox() error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if c.outbox == nil {
+		return nil
+	}
+	return c.outbox.flush(func(message []byte) error {
+		_, err := c.writeLocked(message)
+		return err
+	})
 }
 
 //JSON returns a response string in JSON format from the
@@ -161,6 +558,28 @@ func (c *Client) PubSubId() string {
 	return c.response.PubSubId
 }
 
+//PubSubTable returns the name of the table the message published by the pubsubsql server originates from.
+//The table is looked up by PubSubId among the active subscriptions of the Client;
+//an empty string is returned when the subscription is no longer active.
+func (c *Client) PubSubTable() string {
+	if s := c.subscriptions[c.response.PubSubId]; s != nil {
+		return s.table
+	}
+	return ""
+}
+
+//RequestId returns the request id of the command that produced the current response.
+//Messages published by the pubsubsql server have request id 0.
+func (c *Client) RequestId() uint32 {
+	return c.responseId
+}
+
+//BatchIndex returns the zero based index of the current batch within the result set.
+//The pubsubsql server splits large result sets into batches that NextRow reads one by one.
+func (c *Client) BatchIndex() int {
+	return c.batch
+}
+
 //RowCount returns the number of rows in the result set returned by the pubsubsql server.
 func (c *Client) RowCount() int {
 	return c.response.Rows
@@ -168,9 +587,13 @@ func (c *Client) RowCount() int {
 
 //NextRow is used to move to the next row in the result set returned by the pubsubsql server.
 //When called for the first time, NextRow moves to the first row in the result set.
-//Returns false when all rows are read or if there is an error.
-//To find out if false was returned because of an error, use Ok or Failed functions.
+//Returns false when all rows are read or if there is an error, the error is returned as well.
 func (c *Client) NextRow() (bool, error) {
+	c.enter("NextRow")
+	defer c.leave()
+	if c.failed {
+		c.misuse("NextRow called after Execute failed")
+	}
 	for {
 		// no result set
 		if c.response.Rows == 0 {
@@ -182,6 +605,9 @@ func (c *Client) NextRow() (bool, error) {
 		// the current record is valid
 		c.record++
 		if c.record <= (c.response.Torow - c.response.Fromrow) {
+			if c.filtered() {
+				continue
+			}
 			return true, nil
 		}
 		// we reached the end of result set
@@ -198,11 +624,16 @@ func (c *Client) NextRow() (bool, error) {
 		}
 		// should not happen but check anyway
 		// when RequestId is 0 it means we are reading published data
-		if header.RequestId > 0 && header.RequestId != c.requestId {
-			return false, errors.New("protocol error")
+		if header.RequestId > 0 && header.RequestId != c.commandId {
+			c.closeWith(CloseProtocol)
+			return false, ErrProtocol
 		}
 		// we got another batch unmarshall the data
+		c.setEnvelope(header.RequestId, c.batch+1)
 		err = c.unmarshalJSON(bytes)
+		if err == nil && header.RequestId == 0 {
+			err = c.transform()
+		}
 		if err != nil {
 			return false, err
 		}
@@ -213,6 +644,9 @@ func (c *Client) NextRow() (bool, error) {
 //Value returns the value within the current row for the given column name.
 //If the column name does not exist, Value returns an empty string.
 func (c *Client) Value(column string) string {
+	if c.record < 0 && len(c.response.Data) > 0 {
+		c.misuse("Value called before NextRow")
+	}
 	ordinal, ok := c.columns[column]
 	if !ok {
 		return ""
@@ -251,33 +685,74 @@ func (c *Client) Columns() []string {
 
 //WaitForPubSub waits until the pubsubsql server publishes a message for
 // the subscribed Client or until the timeout interval elapses.
-//Returns false when timeout interval elapses or if there is and error.
-//To find out if false was returned because of an error, use Ok or Failed functions.
+//Returns an error when the timeout interval elapses or if reading fails.
 func (c *Client) WaitForPubSub(timeout int) error {
+	return c.waitForPubSub(context.Background(), timeout)
+}
+
+// waitForPubSub waits for a published message, ctx only bounds the automatic reconnection
+func (c *Client) waitForPubSub(ctx context.Context, timeout int) error {
+	c.enter("WaitForPubSub")
+	defer c.leave()
 	var bytes []byte
-	log.Println("Waiting for PUB SUB...")
+	c.trace("Waiting for PUB SUB...")
+	if !c.waitIntake(timeout) {
+		return ErrTimeout
+	}
 	for {
 		c.reset()
 		// process backlog first
-		bytes = c.popBacklog()
-		if len(bytes) > 0 {
-			fmt.Println("backlog has data, returning that data")
-			return c.unmarshalJSON(bytes)
+		message, ok := c.popBacklog()
+		if ok {
+			c.setEnvelope(0, 0)
+			err := c.unmarshalJSON(message.bytes)
+			if err != nil {
+				return err
+			}
+			c.heard(message.received)
+			if c.expired(message) || c.withheld() {
+				continue
+			}
+			if err = c.transform(); err != nil {
+				return err
+			}
+			c.delivered(message.received)
+			return nil
+		}
+		if err := c.reconnect(ctx); err != nil {
+			return err
 		}
+		c.current = c.connection(true)
 		header, temp, err, timedout := c.readTimeout(int64(timeout))
 		bytes = temp
 		if err != nil {
-			log.Println("error")
+			c.trace("error")
+			if c.reconnectable() {
+				continue
+			}
 			return err
 		}
 		if timedout {
-			log.Println("timed out")
-			return errors.New("Timeout")
+			c.trace("timed out")
+			return ErrTimeout
 		}
-		log.Printf("got header request id: %d\n", header.RequestId)
+		c.trace("got header request id: %d\n", header.RequestId)
 		if header.RequestId == 0 {
-			log.Println("got data")
-			return c.unmarshalJSON(bytes)
+			c.trace("got data")
+			c.setEnvelope(0, 0)
+			err = c.unmarshalJSON(bytes)
+			if err != nil {
+				return err
+			}
+			c.heard(_NOW())
+			if c.withheld() {
+				continue
+			}
+			if err = c.transform(); err != nil {
+				return err
+			}
+			c.delivered(_NOW())
+			return nil
 		}
 		// c is not pubsub message; are we reading abandoned cursor?
 		// ignore and keep trying
@@ -285,24 +760,52 @@ func (c *Client) WaitForPubSub(timeout int) error {
 	return nil
 }
 
-func (c *Client) popBacklog() []byte {
+func (c *Client) setEnvelope(requestId uint32, batch int) {
+	c.responseId = requestId
+	c.batch = batch
+}
+
+func (c *Client) popBacklog() (backlogMessage, bool) {
 	element := c.backlog.Front()
 	if element != nil {
-		bytes := element.Value.([]byte)
+		message := element.Value.(backlogMessage)
 		c.backlog.Remove(element)
-		return bytes
+		return message, true
 	}
-	return nil
+	return backlogMessage{}, false
+}
+
+// expired drops the unmarshaled backlog message when it is older than the maximum age of its subscription
+func (c *Client) expired(message backlogMessage) bool {
+	s := c.subscriptions[c.response.PubSubId]
+	if s == nil || s.maxAge <= 0 || since(message.received) <= s.maxAge {
+		return false
+	}
+	s.stats.Dropped++
+	c.report(&DroppedError{PubSubId: s.pubsubId, Age: since(message.received)})
+	return true
 }
 
 func (c *Client) unmarshalJSON(bytes []byte) error {
 	c.rawjson = bytes
+	c.remember(bytes)
+	start := _NOW()
 	err := json.Unmarshal(bytes, &c.response)
+	c.recordDecode(len(bytes), since(start))
 	if err != nil {
+		if c.observer != nil {
+			c.observer.OnDecodeError(bytes, err)
+		}
+		c.report(err)
 		return err
 	}
+	c.recordReceived(len(bytes))
+	c.checkAction(bytes)
 	if c.response.Status != "ok" {
-		return errors.New(fmt.Sprintf("response error: %s", c.response.Msg))
+		return &ResponseError{Message: c.response.Msg}
+	}
+	if err = c.enforceLimits(); err != nil {
+		return err
 	}
 	c.setColumns()
 	return nil
@@ -318,38 +821,92 @@ func (c *Client) setColumns() {
 	}
 }
 
+// backlogMessage is a pubsub message received while waiting for a command response
+type backlogMessage struct {
+	bytes    []byte
+	received time.Time
+}
+
 func (c *Client) reset() {
 	c.response.reset()
 	c.rawjson = nil
 	c.record = -1
 }
 
-func (c *Client) write(message string) error {
+// write sends the message on the connection and returns the request id assigned to it
+func (c *Client) write(rw *netHelper, message string) (uint32, error) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	return c.writeOnLocked(rw, []byte(message))
+}
+
+func (c *Client) writeLocked(message []byte) (uint32, error) {
+	return c.writeOnLocked(&c.rw, message)
+}
+
+func (c *Client) writeOnLocked(rw *netHelper, message []byte) (uint32, error) {
+	if err := checkSize(len(message)); err != nil {
+		return c.requestId, err
+	}
 	c.requestId++
-	if !c.rw.valid() {
-		return errors.New("Not connected")
+	if !rw.valid() {
+		return c.requestId, ErrNotConnected
+	}
+	if c.pacing.observe(_NOW()) {
+		c.applyWriteModeLocked()
 	}
-	err := c.rw.writeHeaderAndMessage(c.requestId, []byte(message))
+	err := rw.writeHeaderAndMessageTimeout(c.requestId, message, c.writeTimeout)
 	if err != nil {
-		return err
+		c.closeLocked(CloseWriteError)
+		return c.requestId, err
 	}
-	return nil
+	if c.observer != nil {
+		c.observer.OnFrameWrite(c.requestId, message)
+		if observer, ok := c.observer.(CorrelationObserver); ok && c.tag != "" {
+			observer.OnTag(c.requestId, c.tag)
+		}
+	}
+	if c.sampler != nil && c.sampler.pick() {
+		c.sampler.write("sent", c.requestId, c.tag, c.redactCommand(message))
+	}
+	return c.requestId, nil
 }
 
-func (c *Client) readTimeout(timeout int64) (header *netHeader, bytes []byte, err error, timedout bool) {
-	if !c.rw.valid() {
-		err = errors.New("Not connected")
+func (c *Client) readTimeout(timeout int64) (header *Header, bytes []byte, err error, timedout bool) {
+	rw := c.current
+	if rw == nil {
+		rw = &c.rw
+	}
+	// a failed Stream from another goroutine closes the connection under wmu
+	c.wmu.Lock()
+	conn := rw.conn
+	c.wmu.Unlock()
+	if conn == nil {
+		err = ErrNotConnected
 		return
 	}
-	header, bytes, err, timedout = c.rw.readMessageTimeout(timeout)
+	header, bytes, err, timedout = rw.readMessageTimeout(conn, timeout)
+	if err != nil {
+		c.closeWith(readErrorReason(err))
+		return
+	}
+	if !timedout {
+		c.cadence.observe(_NOW())
+		if c.observer != nil {
+			c.observer.OnFrameRead(header.RequestId, bytes)
+		}
+		if c.sampler != nil && c.sampler.pick() {
+			c.sampler.write("received", header.RequestId, "", c.redactResponse(bytes))
+		}
+	}
 	return
 }
 
-func (c *Client) read() (header *netHeader, bytes []byte, err error) {
-	var MAX_READ_TIMEOUT_MILLISECONDS int64 = 1000 * 60 * 3
-	header, bytes, err, timeout := c.readTimeout(MAX_READ_TIMEOUT_MILLISECONDS)
+func (c *Client) read() (header *Header, bytes []byte, err error) {
+	header, bytes, err, timeout := c.readTimeout(int64(c.cadence.timeout() / time.Millisecond))
 	if timeout {
-		err = errors.New("Read timed out")
+		c.closeWith(CloseWatchdog)
+		err = ErrReadTimeout
 	}
 
 	return
@@ -12,8 +12,13 @@
 package pubsubsql
 
 import (
+	"encoding/json"
 	. "gopkg.in/check.v1"
+	"net"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func Test(t *testing.T) { TestingT(t) }
@@ -22,6 +27,49 @@ type TestSuite struct{}
 
 var _ = Suite(&TestSuite{})
 
+// newPipeClient returns a Client connected to an in-memory server that
+// answers each request with the next response and then closes the connection.
+// Responses starting with "pubsub:" are published after the server reads the
+// next request, just before its reply, or at the end of the script.
+// Streamed commands are read without a reply like the pubsubsql server does.
+func newPipeClient(responses ...string) *Client {
+	client := new(Client)
+	client.rw.set(newPipeServer(responses...), _CLIENT_DEFAULT_BUFFER_SIZE)
+	return client
+}
+
+// newPipeServer returns the client end of a connection to the in-memory server of newPipeClient
+func newPipeServer(responses ...string) net.Conn {
+	conn, server := net.Pipe()
+	go func() {
+		rw := newnetHelper(server, _CLIENT_DEFAULT_BUFFER_SIZE)
+		defer rw.close()
+		var published []string
+		for _, response := range responses {
+			if strings.HasPrefix(response, "pubsub:") {
+				published = append(published, response[len("pubsub:"):])
+				continue
+			}
+			header, bytes, err := rw.readMessage()
+			for err == nil && strings.HasPrefix(string(bytes), "stream ") {
+				header, bytes, err = rw.readMessage()
+			}
+			if err != nil {
+				return
+			}
+			for _, message := range published {
+				rw.writeHeaderAndMessage(0, []byte(message))
+			}
+			published = nil
+			rw.writeHeaderAndMessage(header.RequestId, []byte(response))
+		}
+		for _, message := range published {
+			rw.writeHeaderAndMessage(0, []byte(message))
+		}
+	}()
+	return conn
+}
+
 func (s *TestSuite) TestResponseData(c *C) {
 	rd_empty := responseData{}
 
@@ -41,3 +89,137 @@ func (s *TestSuite) TestResponseData(c *C) {
 	rd.reset()
 	c.Assert(rd, DeepEquals, rd_empty)
 }
+
+func (s *TestSuite) TestResponseAliases(c *C) {
+	var rd responseData
+	err := json.Unmarshal([]byte(`{"status":"ok","action":"select","id":"1","rowCount":2,"from_row":1,"to_row":2,"columns":["a"],"data":[["x"],["y"]]}`), &rd)
+	c.Assert(err, IsNil)
+	c.Assert(rd.Rows, Equals, 2)
+	c.Assert(rd.Fromrow, Equals, 1)
+	c.Assert(rd.Torow, Equals, 2)
+	c.Assert(rd.Data, HasLen, 2)
+
+	rd.reset()
+	err = json.Unmarshal([]byte(`{"status":"err","message":"renamed","msg":"canonical","pubsub_id":"7"}`), &rd)
+	c.Assert(err, IsNil)
+	c.Assert(rd.Msg, Equals, "canonical")
+	c.Assert(rd.PubSubId, Equals, "7")
+}
+
+func (s *TestSuite) TestEnvelope(c *C) {
+	client := newPipeClient(
+		`{"status":"ok","action":"subscribe","pubsubid":"1"}`,
+		`pubsub:{"status":"ok","action":"add","pubsubid":"1"}`,
+		`{"status":"ok","action":"status"}`,
+	)
+	c.Assert(client.Execute("subscribe * from stocks"), IsNil)
+	c.Assert(client.Execute("status"), IsNil)
+	c.Assert(client.RequestId(), Equals, uint32(2))
+	c.Assert(client.BatchIndex(), Equals, 0)
+	c.Assert(client.WaitForPubSub(1000), IsNil)
+	c.Assert(client.RequestId(), Equals, uint32(0))
+}
+
+func (s *TestSuite) TestConcurrentStream(c *C) {
+	client := new(Client)
+	conn, server := net.Pipe()
+	client.rw.set(conn, _CLIENT_DEFAULT_BUFFER_SIZE)
+	received := make(chan string)
+	go func() {
+		rw := newnetHelper(server, _CLIENT_DEFAULT_BUFFER_SIZE)
+		for {
+			_, bytes, err := rw.readMessage()
+			if err != nil {
+				close(received)
+				return
+			}
+			received <- string(bytes)
+		}
+	}()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				client.Stream("update stocks set bid = 1")
+			}
+			client.StreamBatch([]string{"update stocks set bid = 2", "update stocks set bid = 3"})
+		}()
+	}
+	go func() {
+		wg.Wait()
+		client.rw.close()
+	}()
+	count := 0
+	for message := range received {
+		c.Assert(strings.HasPrefix(message, "stream update stocks set bid = "), Equals, true)
+		count++
+	}
+	c.Assert(count, Equals, 120)
+}
+
+func (s *TestSuite) TestWriteTimeout(c *C) {
+	client := new(Client)
+	// nobody reads from the other end of the pipe
+	conn, _ := net.Pipe()
+	client.rw.set(conn, _CLIENT_DEFAULT_BUFFER_SIZE)
+	client.SetWriteTimeout(10 * time.Millisecond)
+	c.Assert(client.Stream("update stocks set bid = 1"), Equals, ErrWriteTimeout)
+	c.Assert(client.Connected(), Equals, false)
+}
+
+func (s *TestSuite) TestWriteTimeoutWhileWaiting(c *C) {
+	client := new(Client)
+	conn, _ := net.Pipe()
+	client.rw.set(conn, _CLIENT_DEFAULT_BUFFER_SIZE)
+	client.SetWriteTimeout(10 * time.Millisecond)
+	done := make(chan error)
+	go func() {
+		done <- client.WaitForPubSub(1000)
+	}()
+	c.Assert(client.Stream("update stocks set bid = 1"), Equals, ErrWriteTimeout)
+	// the waiting reader notices the closed connection
+	c.Assert(<-done, NotNil)
+	c.Assert(client.Connected(), Equals, false)
+}
+
+func (s *TestSuite) TestStreamConfirmed(c *C) {
+	client := newPipeClient(
+		`{"status":"ok","action":"select","rows":1,"fromrow":1,"torow":1,"columns":["ticker"],"data":[["IBM"]]}`,
+		`{"status":"ok","action":"select","rows":0,"fromrow":0,"torow":0}`,
+		`{"status":"ok","action":"status"}`,
+	)
+	c.Assert(client.StreamConfirmed("insert into stocks (ticker) values (IBM)", "select * from stocks where ticker = IBM"), IsNil)
+	c.Assert(client.RowCount(), Equals, 1)
+	c.Assert(client.StreamConfirmed("insert into stocks (ticker) values (MSFT)", "select * from stocks where ticker = MSFT"), Equals, ErrNotConfirmed)
+	c.Assert(client.StreamConfirmed("insert into stocks (ticker) values (ORCL)", ""), IsNil)
+	c.Assert(client.Action(), Equals, "status")
+	c.Assert(client.StreamConfirmed("insert into stocks (ticker) values (ORCL)", "delete from stocks"), ErrorMatches, "Confirmation must be a select command.*")
+}
+
+func (s *TestSuite) TestDualConnection(c *C) {
+	client := newPipeClient(
+		`{"status":"ok","action":"select","rows":1,"fromrow":1,"torow":1,"columns":["ticker"],"data":[["IBM"]]}`,
+	)
+	client.SetDualConnection(true)
+	client.subrw.set(newPipeServer(
+		`{"status":"ok","action":"subscribe","pubsubid":"1"}`,
+		`pubsub:{"status":"ok","action":"add","pubsubid":"1","rows":1,"fromrow":1,"torow":1,"columns":["ticker"],"data":[["MSFT"]]}`,
+	), _CLIENT_DEFAULT_BUFFER_SIZE)
+	sub, err := client.Subscribe("stocks", nil)
+	c.Assert(err, IsNil)
+	c.Assert(sub.PubSubId(), Equals, "1")
+	// the select is answered on the command connection while the snapshot waits on the other
+	c.Assert(client.Execute("select * from stocks"), IsNil)
+	more, err := client.NextRow()
+	c.Assert(more, Equals, true)
+	c.Assert(client.Value("ticker"), Equals, "IBM")
+	c.Assert(client.WaitForPubSub(1000), IsNil)
+	c.Assert(client.Action(), Equals, "add")
+	more, err = client.NextRow()
+	c.Assert(more, Equals, true)
+	c.Assert(client.Value("ticker"), Equals, "MSFT")
+	client.Disconnect()
+	c.Assert(client.subrw.valid(), Equals, false)
+}
@@ -0,0 +1,34 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"time"
+)
+
+// source of the timestamps the Client compares itself: heartbeats, message ages, lag,
+// decode times and write pacing. Deadlines of net.Conn and context.Context are compared
+// by the runtime against the real clock, so they keep using time.Now and time.Until.
+// time.Now carries a monotonic reading, so the durations between its results are not
+// affected when NTP steps the wall clock; the timestamps must therefore never be stripped
+// with Round(0) or serialized before they are compared. Tests replace it to simulate clocks.
+var _NOW = time.Now
+
+// since returns the time elapsed since t, zero when t lacks a monotonic reading
+// and the wall clock was stepped back since it was taken
+func since(t time.Time) time.Duration {
+	elapsed := _NOW().Sub(t)
+	if elapsed < 0 {
+		return 0
+	}
+	return elapsed
+}
@@ -0,0 +1,57 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	. "gopkg.in/check.v1"
+	"strings"
+	"time"
+)
+
+func (s *TestSuite) TestTimestampsAreMonotonic(c *C) {
+	client := newPipeClient(
+		`{"status":"ok","action":"subscribe","pubsubid":"1"}`,
+		`pubsub:{"status":"ok","action":"add","pubsubid":"1"}`,
+		`{"status":"ok","action":"status"}`,
+	)
+	c.Assert(client.Execute("subscribe * from stocks"), IsNil)
+	c.Assert(client.Execute("status"), IsNil)
+	received := client.backlog.Front().Value.(backlogMessage).received
+	// only timestamps with a monotonic reading are immune to wall clock steps
+	c.Assert(strings.Contains(received.String(), " m="), Equals, true)
+	c.Assert(strings.Contains(client.Subscription("1").heard.String(), " m="), Equals, true)
+}
+
+func (s *TestSuite) TestWallClockStepBack(c *C) {
+	defer func() { _NOW = time.Now }()
+	// without monotonic readings a step back of the wall clock makes time appear to run backwards
+	wall := time.Now().Round(0)
+	_NOW = func() time.Time { return wall }
+
+	client := newPipeClient(
+		`{"status":"ok","action":"subscribe","pubsubid":"1"}`,
+		`pubsub:{"status":"ok","action":"add","pubsubid":"1"}`,
+		`{"status":"ok","action":"status"}`,
+	)
+	c.Assert(client.Execute("subscribe * from stocks"), IsNil)
+	sub := client.Subscription("1")
+	sub.ExpectHeartbeat(time.Minute, func(*Subscription) { c.Error("stale after the clock was stepped back") })
+	sub.SetMaxAge(time.Minute)
+	c.Assert(client.Execute("status"), IsNil)
+
+	wall = wall.Add(-time.Hour)
+	c.Assert(client.CheckHeartbeats(), IsNil)
+	c.Assert(client.WaitForPubSub(1000), IsNil)
+	c.Assert(sub.Stats().Lag, Equals, time.Duration(0))
+	c.Assert(sub.Dropped(), Equals, uint64(0))
+	c.Assert(since(wall.Add(time.Hour)), Equals, time.Duration(0))
+}
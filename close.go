@@ -0,0 +1,104 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"io"
+)
+
+//CloseReason describes why the connection to the pubsubsql server was closed.
+type CloseReason int
+
+const (
+	//CloseNone means the connection was not closed yet.
+	CloseNone CloseReason = iota
+	//CloseUser means Disconnect was called.
+	CloseUser
+	//CloseReadError means reading from the connection failed.
+	CloseReadError
+	//CloseWriteError means writing to the connection failed or timed out.
+	CloseWriteError
+	//CloseServer means the pubsubsql server closed the connection.
+	CloseServer
+	//CloseWatchdog means the pubsubsql server did not respond within the read timeout.
+	CloseWatchdog
+	//CloseProtocol means the Client received a message it did not expect and lost track of the protocol.
+	CloseProtocol
+)
+
+var _CLOSE_REASONS = []string{"none", "user requested", "read error", "write error", "server closed", "watchdog", "protocol desync"}
+
+func (r CloseReason) String() string {
+	if r < 0 || int(r) >= len(_CLOSE_REASONS) {
+		return "unknown"
+	}
+	return _CLOSE_REASONS[r]
+}
+
+//StateHandler is called when the Client connects or its connection is closed.
+//The handler is called synchronously and must not call methods of the Client.
+type StateHandler func(connected bool, reason CloseReason)
+
+//SetStateHandler installs the handler notified about connection state changes.
+//Pass nil to remove the handler.
+func (c *Client) SetStateHandler(handler StateHandler) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	c.stateHandler = handler
+}
+
+//CloseReason returns why the last connection was closed, CloseNone while connected.
+func (c *Client) CloseReason() CloseReason {
+	return c.closeReason
+}
+
+// closeWith closes the connection recording the reason
+func (c *Client) closeWith(reason CloseReason) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	c.closeLocked(reason)
+}
+
+func (c *Client) closeLocked(reason CloseReason) {
+	if !c.rw.valid() {
+		return
+	}
+	c.rw.close()
+	c.subrw.close()
+	c.closeReason = reason
+	if reason != CloseUser {
+		c.report(&CloseError{Reason: reason})
+	}
+	if c.stateHandler != nil {
+		c.stateHandler(false, reason)
+	}
+}
+
+// readErrorReason classifies a read error
+func readErrorReason(err error) CloseReason {
+	if err == io.EOF {
+		return CloseServer
+	}
+	return CloseReadError
+}
+
+// interrupt closes the connections to unblock a pending read from another goroutine,
+// the reader then records the close
+func (c *Client) interrupt() {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	for _, rw := range []*netHelper{&c.rw, &c.subrw} {
+		if rw.valid() {
+			rw.conn.Close()
+		}
+	}
+}
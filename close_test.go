@@ -0,0 +1,44 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+func (s *TestSuite) TestCloseReasonServer(c *C) {
+	client := newPipeClient(`{"status":"ok"}`)
+	var reasons []CloseReason
+	client.SetStateHandler(func(connected bool, reason CloseReason) {
+		reasons = append(reasons, reason)
+	})
+	c.Assert(client.Execute("status"), IsNil)
+	// the server closed the connection after the only response
+	c.Assert(client.WaitForPubSub(1000), NotNil)
+	c.Assert(client.Connected(), Equals, false)
+	c.Assert(client.CloseReason(), Equals, CloseServer)
+	c.Assert(reasons, DeepEquals, []CloseReason{CloseServer})
+}
+
+func (s *TestSuite) TestCloseReasonWrite(c *C) {
+	client := newPipeClient(`{"status":"ok"}`)
+	c.Assert(client.Execute("status"), IsNil)
+	c.Assert(client.Execute("status"), NotNil)
+	c.Assert(client.CloseReason(), Equals, CloseWriteError)
+}
+
+func (s *TestSuite) TestCloseReasonUser(c *C) {
+	client := newPipeClient()
+	client.Disconnect()
+	c.Assert(client.CloseReason(), Equals, CloseUser)
+	c.Assert(client.CloseReason().String(), Equals, "user requested")
+}
@@ -0,0 +1,224 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"errors"
+	"strings"
+)
+
+//ErrReadOnly is returned when a read-only Client is asked to execute a mutating command.
+var ErrReadOnly = errors.New("Mutating commands are not allowed on a read-only client")
+
+// commands that modify data or table definitions on the server
+var _MUTATING_COMMANDS = map[string]bool{
+	"insert": true,
+	"update": true,
+	"delete": true,
+	"key":    true,
+	"tag":    true,
+}
+
+// commandVerb returns the lower case first word of the command,
+// a leading stream keyword is skipped
+func commandVerb(command string) string {
+	verb, rest := firstWord(command)
+	if verb == "stream" {
+		verb, _ = firstWord(rest)
+	}
+	return verb
+}
+
+// firstWord splits the command into its lower case first word and the rest
+func firstWord(command string) (string, string) {
+	command = strings.TrimSpace(command)
+	if i := strings.IndexAny(command, " \t\r\n"); i >= 0 {
+		return strings.ToLower(command[:i]), command[i:]
+	}
+	return strings.ToLower(command), ""
+}
+
+// commandTable locates the table name in the command and returns it with its position;
+// an empty table is returned for commands without a table such as status
+func commandTable(command string) (table string, start int, end int) {
+	var prev string
+	verb := ""
+	position := 0
+	streamed := false
+	for start, end = nextWord(command, 0); start < end; start, end = nextWord(command, end) {
+		word := strings.ToLower(command[start:end])
+		if position == 0 && word == "stream" && !streamed {
+			streamed = true
+			continue
+		}
+		if position == 0 {
+			verb = word
+		}
+		position++
+		switch verb {
+		case "update", "key", "tag":
+			if position == 2 {
+				return command[start:end], start, end
+			}
+		case "insert":
+			if prev == "into" {
+				return command[start:end], start, end
+			}
+		case "select", "delete", "subscribe", "unsubscribe":
+			if prev == "from" {
+				return command[start:end], start, end
+			}
+		default:
+			return "", 0, 0
+		}
+		prev = word
+	}
+	return "", 0, 0
+}
+
+// nextWord returns the position of the next identifier in the command starting at offset,
+// quoted values are skipped
+func nextWord(command string, offset int) (start int, end int) {
+	for start = offset; start < len(command); start++ {
+		ch := command[start]
+		if ch == '\'' {
+			// skip quoted value, quotes are escaped by doubling them
+			for start++; start < len(command); start++ {
+				if command[start] == '\'' {
+					if start+1 < len(command) && command[start+1] == '\'' {
+						start++
+						continue
+					}
+					break
+				}
+			}
+			continue
+		}
+		if isWordChar(ch) {
+			break
+		}
+	}
+	for end = start; end < len(command) && isWordChar(command[end]); end++ {
+	}
+	return start, end
+}
+
+// isIdentifier returns true if name is a single table or column name
+func isIdentifier(name string) bool {
+	start, end := nextWord(name, 0)
+	return start == 0 && end == len(name) && end > 0
+}
+
+func isWordChar(ch byte) bool {
+	return ch == '_' || ch == '.' || ch == '-' || ('0' <= ch && ch <= '9') || ('a' <= ch && ch <= 'z') || ('A' <= ch && ch <= 'Z')
+}
+
+//QuoteValue returns the value as it must appear in a command composed by hand,
+//quoted with quotes escaped by doubling them unless the value is a single word.
+func QuoteValue(value string) string {
+	return quoteValue(value)
+}
+
+// quoteValue returns the value as it must appear in a command,
+// values that are not a single word are quoted with quotes escaped by doubling them
+func quoteValue(value string) string {
+	if value != "" && isIdentifier(value) {
+		return value
+	}
+	return "'" + strings.Replace(value, "'", "''", -1) + "'"
+}
+
+// prefixTable inserts the prefix in front of the table name in the command
+func prefixTable(command string, prefix string) string {
+	if prefix == "" {
+		return command
+	}
+	table, start, _ := commandTable(command)
+	if table == "" {
+		return command
+	}
+	return command[:start] + prefix + command[start:]
+}
+
+// indexOutsideQuotes returns the index of the first ch at or after offset that is not inside a quoted value or -1
+func indexOutsideQuotes(command string, ch byte, offset int) int {
+	quoted := false
+	for i := offset; i < len(command); i++ {
+		switch {
+		case command[i] == '\'':
+			quoted = !quoted
+		case !quoted && command[i] == ch:
+			return i
+		}
+	}
+	return -1
+}
+
+// addColumnValue adds the column with the value to an insert or update command,
+// false is returned when the command is not a recognized insert or update
+func addColumnValue(command string, column string, value string) (string, bool) {
+	table, _, end := commandTable(command)
+	if table == "" {
+		return command, false
+	}
+	switch commandVerb(command) {
+	case "insert":
+		open := indexOutsideQuotes(command, '(', end)
+		if open < 0 {
+			return command, false
+		}
+		columnsEnd := indexOutsideQuotes(command, ')', open)
+		if columnsEnd < 0 {
+			return command, false
+		}
+		open = indexOutsideQuotes(command, '(', columnsEnd)
+		if open < 0 {
+			return command, false
+		}
+		valuesEnd := indexOutsideQuotes(command, ')', open)
+		if valuesEnd < 0 {
+			return command, false
+		}
+		return command[:columnsEnd] + ", " + column + command[columnsEnd:valuesEnd] + ", " + value + command[valuesEnd:], true
+	case "update":
+		for start, stop := nextWord(command, end); start < stop; start, stop = nextWord(command, stop) {
+			if strings.ToLower(command[start:stop]) == "where" {
+				return strings.TrimRight(command[:start], " \t") + ", " + column + " = " + value + " " + command[start:], true
+			}
+		}
+		return strings.TrimRight(command, " \t") + ", " + column + " = " + value, true
+	}
+	return command, false
+}
+
+// checkCommand validates the command against the client settings before it is sent
+func (c *Client) checkCommand(command string) error {
+	if c.readOnly && _MUTATING_COMMANDS[commandVerb(command)] {
+		return ErrReadOnly
+	}
+	// early check so StreamBatch sends nothing, the encoded message is checked again when it is written
+	if err := checkSize(len(prefixTable(command, c.tablePrefix))); err != nil {
+		return err
+	}
+	if c.policy != nil {
+		return c.policy(command)
+	}
+	return nil
+}
+
+// checkSize rejects a message the pubsubsql server would refuse because of its size
+func checkSize(size int) error {
+	if size > _MAX_COMMAND_SIZE {
+		return &CommandTooLargeError{Size: size, Limit: _MAX_COMMAND_SIZE}
+	}
+	return nil
+}
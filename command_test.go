@@ -0,0 +1,87 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"errors"
+	. "gopkg.in/check.v1"
+	"strings"
+)
+
+func (s *TestSuite) TestCommandVerb(c *C) {
+	c.Assert(commandVerb("  Insert into stocks (ticker) values (IBM)"), Equals, "insert")
+	c.Assert(commandVerb("status"), Equals, "status")
+	c.Assert(commandVerb("stream Delete from stocks"), Equals, "delete")
+	c.Assert(commandVerb(""), Equals, "")
+}
+
+func (s *TestSuite) TestReadOnly(c *C) {
+	client := newPipeClient()
+	client.SetReadOnly(true)
+	c.Assert(client.Execute("delete from stocks"), Equals, ErrReadOnly)
+	c.Assert(client.Stream("update stocks set bid = 1"), Equals, ErrReadOnly)
+	c.Assert(client.Execute("stream delete from stocks"), Equals, ErrReadOnly)
+	c.Assert(client.checkCommand("select * from stocks"), IsNil)
+}
+
+func (s *TestSuite) TestCommandTooLarge(c *C) {
+	client := newPipeClient()
+	command := "insert into stocks (name) values (" + strings.Repeat("x", _MAX_COMMAND_SIZE) + ")"
+	err := client.Execute(command)
+	c.Assert(errors.Is(err, ErrCommandTooLarge), Equals, true)
+	c.Assert(err.(*CommandTooLargeError).Limit, Equals, _MAX_COMMAND_SIZE)
+	c.Assert(err.(*CommandTooLargeError).Size, Equals, len(command))
+	c.Assert(client.Stream(command), ErrorMatches, "Command of .* exceeds the maximum command size of 1048576 bytes")
+}
+
+func (s *TestSuite) TestStreamTooLarge(c *C) {
+	client, received := newCaptureClient()
+	head := "insert into stocks (name) values ("
+	command := head + strings.Repeat("x", _MAX_COMMAND_SIZE-len(head)-1) + ")"
+	c.Assert(client.checkCommand(command), IsNil)
+	// the stream prefix pushes the message over the limit
+	err := client.Stream(command)
+	c.Assert(errors.Is(err, ErrCommandTooLarge), Equals, true)
+	c.Assert(err.(*CommandTooLargeError).Size, Equals, len("stream ")+_MAX_COMMAND_SIZE)
+	c.Assert(client.Connected(), Equals, true)
+	c.Assert(client.Stream("insert into stocks (name) values (IBM)"), IsNil)
+	c.Assert(<-received, Equals, "stream insert into stocks (name) values (IBM)")
+}
+
+func (s *TestSuite) TestCommandTable(c *C) {
+	tables := map[string]string{
+		"insert into stocks (ticker, bid) values (IBM, 12)": "stocks",
+		"insert into stocks(ticker) values ('a from b')":    "stocks",
+		"select * from stocks where ticker = 'IBM'":         "stocks",
+		"select ticker, bid from stocks":                    "stocks",
+		"update stocks set bid = 13 where ticker = IBM":     "stocks",
+		"delete from stocks":                                "stocks",
+		"subscribe skip * from stocks":                      "stocks",
+		"unsubscribe from stocks":                           "stocks",
+		"key stocks ticker":                                 "stocks",
+		"tag stocks market":                                 "stocks",
+		"stream insert into stocks (ticker) values (IBM)":   "stocks",
+		"status": "",
+	}
+	for command, table := range tables {
+		t, _, _ := commandTable(command)
+		c.Assert(t, Equals, table, Commentf(command))
+	}
+}
+
+func (s *TestSuite) TestPrefixTable(c *C) {
+	c.Assert(prefixTable("select * from orders where id = 1", "tenantA_"), Equals, "select * from tenantA_orders where id = 1")
+	c.Assert(prefixTable("insert into orders(a) values ('it''s from x')", "t_"), Equals, "insert into t_orders(a) values ('it''s from x')")
+	c.Assert(prefixTable("stream insert into orders (a) values (1)", "t_"), Equals, "stream insert into t_orders (a) values (1)")
+	c.Assert(prefixTable("status", "t_"), Equals, "status")
+	c.Assert(prefixTable("key orders id", ""), Equals, "key orders id")
+}
@@ -0,0 +1,46 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"io"
+	"net"
+)
+
+//NewClientOverConn returns a Client running the pubsubsql protocol over rw, such as an SSH channel,
+//a serial link or an in-process pipe, instead of a TCP connection it dials itself.
+//Read and write timeouts are honored also when rw is not a net.Conn supporting deadlines.
+//Disconnect closes rw, a later Connect replaces it with a TCP connection.
+func NewClientOverConn(rw io.ReadWriteCloser) *Client {
+	conn, ok := rw.(net.Conn)
+	if !ok {
+		conn = pipeConn(rw)
+	}
+	c := new(Client)
+	c.attach(conn, nil)
+	return c
+}
+
+// pipeConn adapts rw to a net.Conn with deadlines by copying through an in-memory pipe,
+// closing either side closes the other
+func pipeConn(rw io.ReadWriteCloser) net.Conn {
+	conn, end := net.Pipe()
+	go func() {
+		io.Copy(end, rw)
+		end.Close()
+	}()
+	go func() {
+		io.Copy(rw, end)
+		rw.Close()
+	}()
+	return conn
+}
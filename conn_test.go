@@ -0,0 +1,43 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	. "gopkg.in/check.v1"
+	"io"
+)
+
+// plainStream hides the net.Conn methods of the connection
+type plainStream struct {
+	io.ReadWriteCloser
+	closed chan bool
+}
+
+func (this *plainStream) Close() error {
+	this.closed <- true
+	return this.ReadWriteCloser.Close()
+}
+
+func (s *TestSuite) TestNewClientOverConn(c *C) {
+	rw := &plainStream{
+		ReadWriteCloser: newPipeServer(`{"status":"ok","action":"subscribe","pubsubid":"1"}`, `{"status":"ok","action":"status"}`),
+		closed:          make(chan bool, 1),
+	}
+	client := NewClientOverConn(rw)
+	c.Assert(client.Connected(), Equals, true)
+	c.Assert(client.Execute("subscribe * from stocks"), IsNil)
+	// the read deadline works without deadlines on the stream
+	c.Assert(client.WaitForPubSub(10), Equals, ErrTimeout)
+	c.Assert(client.Execute("status"), IsNil)
+	client.Disconnect()
+	c.Assert(<-rw.closed, Equals, true)
+}
@@ -0,0 +1,131 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"context"
+	"time"
+)
+
+// longest single wait of WaitForPubSubContext, bounds the delay of a cancellation
+// that arrives while the read deadline is being set
+var _CONTEXT_WAIT_SLICE = time.Second
+
+//ExecuteContext executes the command like Execute until ctx is done.
+//When ctx is done first, the connection is closed to abort the pending write or read,
+//since the rest of the response can not be told apart from later responses, and ctx.Err() is returned.
+func (c *Client) ExecuteContext(ctx context.Context, command string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	stop := c.watchConnection(ctx)
+	return stop(c.executeContext(ctx, command))
+}
+
+//NextRowContext moves to the next row like NextRow until ctx is done.
+//When ctx is done while the next batch is read, the connection is closed and ctx.Err() is returned.
+func (c *Client) NextRowContext(ctx context.Context) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	stop := c.watchConnection(ctx)
+	ok, err := c.NextRow()
+	return ok, stop(err)
+}
+
+//WaitForPubSubContext waits like WaitForPubSub until the pubsubsql server publishes a message or ctx is done.
+//Unlike the other context methods, a done ctx only interrupts the wait and the connection stays open.
+func (c *Client) WaitForPubSubContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	stop := c.watch(ctx, c.wake)
+	for {
+		wait := _CONTEXT_WAIT_SLICE
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			wait = time.Until(deadline)
+		}
+		err := ErrTimeout
+		if wait > 0 {
+			err = c.waitForPubSub(ctx, int(wait/time.Millisecond))
+		}
+		if err != ErrTimeout {
+			return stop(err)
+		}
+		if ctx.Err() != nil {
+			stop(nil)
+			return ctx.Err()
+		}
+	}
+}
+
+// watchConnection closes the connections when ctx is done before the returned function is called
+// with the result of the operation, which then returns ctx.Err(). The Client is left disconnected
+// even when the operation completed before the connections were closed.
+func (c *Client) watchConnection(ctx context.Context) func(error) error {
+	aborted := c.guard(ctx, c.interrupt)
+	return func(err error) error {
+		if aborted() {
+			c.closeWith(CloseReadError)
+			return ctx.Err()
+		}
+		return err
+	}
+}
+
+// watch calls abort when ctx is done before the returned function is called with the result of the operation,
+// which then returns ctx.Err() in place of the error of the aborted operation
+func (c *Client) watch(ctx context.Context, abort func()) func(error) error {
+	aborted := c.guard(ctx, abort)
+	return func(err error) error {
+		if aborted() && err != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+}
+
+// guard calls abort when ctx is done before the returned function is called,
+// which reports whether abort was called
+func (c *Client) guard(ctx context.Context, abort func()) func() bool {
+	if ctx.Done() == nil {
+		return func() bool {
+			return false
+		}
+	}
+	done := make(chan struct{})
+	aborted := make(chan bool, 1)
+	go func() {
+		select {
+		case <-ctx.Done():
+			abort()
+			aborted <- true
+		case <-done:
+			aborted <- false
+		}
+	}()
+	return func() bool {
+		close(done)
+		return <-aborted
+	}
+}
+
+// wake makes a pending read of published messages time out without closing the connections
+func (c *Client) wake() {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	for _, rw := range []*netHelper{&c.rw, &c.subrw} {
+		if rw.valid() {
+			rw.conn.SetReadDeadline(time.Now())
+		}
+	}
+}
@@ -0,0 +1,74 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"context"
+	. "gopkg.in/check.v1"
+	"net"
+	"time"
+)
+
+func (s *TestSuite) TestExecuteContext(c *C) {
+	client := newPipeClient(`{"status":"ok","action":"status"}`)
+	c.Assert(client.ExecuteContext(context.Background(), "status"), IsNil)
+
+	// the server reads the command but never answers
+	conn, server := net.Pipe()
+	go func() {
+		newnetHelper(server, _CLIENT_DEFAULT_BUFFER_SIZE).readMessage()
+	}()
+	client = new(Client)
+	client.rw.set(conn, _CLIENT_DEFAULT_BUFFER_SIZE)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	c.Assert(client.ExecuteContext(ctx, "status"), Equals, context.DeadlineExceeded)
+	c.Assert(client.Connected(), Equals, false)
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	c.Assert(client.ExecuteContext(canceled, "status"), Equals, context.Canceled)
+}
+
+func (s *TestSuite) TestAbortAfterSuccess(c *C) {
+	client := newPipeClient(`{"status":"ok","action":"status"}`)
+	ctx, cancel := context.WithCancel(context.Background())
+	stop := client.watchConnection(ctx)
+	c.Assert(client.Execute("status"), IsNil)
+	// ctx is done after the response was read but before the result is returned
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+	c.Assert(stop(nil), Equals, context.Canceled)
+	c.Assert(client.Connected(), Equals, false)
+}
+
+func (s *TestSuite) TestWaitForPubSubContext(c *C) {
+	client := newPipeClient(
+		`{"status":"ok","action":"subscribe","pubsubid":"1"}`,
+		`{"status":"ok","action":"status"}`,
+	)
+	c.Assert(client.Execute("subscribe * from stocks"), IsNil)
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	start := time.Now()
+	c.Assert(client.WaitForPubSubContext(ctx), Equals, context.Canceled)
+	c.Assert(time.Since(start) < _CONTEXT_WAIT_SLICE, Equals, true)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	c.Assert(client.WaitForPubSubContext(ctx), Equals, context.DeadlineExceeded)
+	// the connection is still usable
+	c.Assert(client.Execute("status"), IsNil)
+}
@@ -0,0 +1,41 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+//CorrelationObserver may be implemented by an Observer to receive the correlation tag
+//of every tagged command with its request id.
+type CorrelationObserver interface {
+	OnTag(requestId uint32, tag string)
+}
+
+//SetCorrelationTag sets the tag of the commands written from now on, for example the id of the
+//request the application is serving, an empty tag stops tagging. Sampled payloads are logged
+//with the tag and a CorrelationObserver receives it with the request id, so application logs
+//can be joined with the logs of the Client and, by request id, with those of the pubsubsql server.
+func (c *Client) SetCorrelationTag(tag string) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	c.tag = tag
+}
+
+//CorrelationTag returns the tag set by SetCorrelationTag.
+func (c *Client) CorrelationTag() string {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	return c.tag
+}
+
+//LastRequestId returns the request id of the last command sent by Execute, also when it failed,
+//while RequestId returns the request id of the current response.
+func (c *Client) LastRequestId() uint32 {
+	return c.commandId
+}
@@ -0,0 +1,46 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"bytes"
+	. "gopkg.in/check.v1"
+	"log"
+)
+
+type correlationObserver struct {
+	recordingObserver
+	tags map[uint32]string
+}
+
+func (o *correlationObserver) OnTag(requestId uint32, tag string) {
+	o.tags[requestId] = tag
+}
+
+func (s *TestSuite) TestCorrelationTag(c *C) {
+	client := newPipeClient(`{"status":"ok","action":"status"}`, `{"status":"err","msg":"no"}`)
+	observer := &correlationObserver{tags: make(map[uint32]string)}
+	client.SetObserver(observer)
+	var buffer bytes.Buffer
+	client.SetPayloadSampling(1, 0, log.New(&buffer, "", 0))
+
+	client.SetCorrelationTag("req-42")
+	c.Assert(client.CorrelationTag(), Equals, "req-42")
+	c.Assert(client.Execute("status"), IsNil)
+	c.Assert(client.LastRequestId(), Equals, uint32(1))
+	c.Assert(buffer.String(), Matches, "(?s)pubsubsql: sent \\[req-42\\] request 1 \\(6 bytes\\): status\n.*")
+
+	client.SetCorrelationTag("")
+	c.Assert(client.Execute("select * from stocks"), NotNil)
+	c.Assert(client.LastRequestId(), Equals, uint32(2))
+	c.Assert(observer.tags, DeepEquals, map[uint32]string{1: "req-42"})
+}
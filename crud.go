@@ -0,0 +1,239 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// largest command accepted by the pubsubsql server
+var _MAX_COMMAND_SIZE = 1024 * 1024
+
+//InsertCommands composes the insert commands adding the rows to the table.
+//The pubsubsql server inserts one row per command, so one command is returned per row,
+//with values quoted only where necessary to keep the commands compact.
+//The table and columns are compiled with CompileInsert, so repeated calls do not validate them again.
+func InsertCommands(table string, columns []string, rows [][]string) ([]string, error) {
+	t, err := CompileInsert(table, columns)
+	if err != nil {
+		return nil, err
+	}
+	buffer := _BUILDERS.get()
+	defer _BUILDERS.put(buffer)
+	commands := make([]string, 0, len(rows))
+	for i, row := range rows {
+		if len(row) != len(columns) {
+			return nil, fmt.Errorf("Row %d has %d values for %d columns", i, len(row), len(columns))
+		}
+		buffer.Reset()
+		t.compose(buffer, row)
+		if buffer.Len() > _MAX_COMMAND_SIZE {
+			return nil, fmt.Errorf("Row %d: %w", i, &CommandTooLargeError{Size: buffer.Len(), Limit: _MAX_COMMAND_SIZE})
+		}
+		commands = append(commands, buffer.String())
+	}
+	return commands, nil
+}
+
+//InsertRows inserts the rows into the table executing the commands composed by InsertCommands.
+//No row is inserted when a command cannot be composed; otherwise inserting stops at the first
+//failed row and the error reports its index.
+func (c *Client) InsertRows(table string, columns []string, rows [][]string) error {
+	commands, err := InsertCommands(table, columns, rows)
+	if err != nil {
+		return err
+	}
+	for i, command := range commands {
+		err = c.Execute(command)
+		if err != nil {
+			return fmt.Errorf("Row %d: %v", i, err)
+		}
+	}
+	return nil
+}
+
+//Upsert updates the row of the table whose keyColumn equals the key value in values,
+//or inserts the row when no row has that key. Action returns update or insert accordingly.
+//When another client inserts the key between the update and the insert, the update is retried.
+func (c *Client) Upsert(table string, keyColumn string, values map[string]string) error {
+	key, ok := values[keyColumn]
+	if !ok {
+		return fmt.Errorf("Key column %s is missing from the values", keyColumn)
+	}
+	changes := make(map[string]string, len(values))
+	for column, value := range values {
+		if column != keyColumn {
+			changes[column] = value
+		}
+	}
+	columns, row := sortedColumns(values)
+	inserts, err := InsertCommands(table, columns, [][]string{row})
+	if err != nil {
+		return err
+	}
+	update := ""
+	if len(changes) > 0 {
+		update, err = updateCommand(table, keyColumn, key, changes)
+		if err != nil {
+			return err
+		}
+	}
+	for attempt := 0; ; attempt++ {
+		if update != "" {
+			err = c.Execute(update)
+			if err != nil || c.response.Rows > 0 {
+				return err
+			}
+		}
+		err = c.Execute(inserts[0])
+		if err == nil || attempt > 0 || !c.keyExists(table, keyColumn, key) {
+			return err
+		}
+		if update == "" {
+			// nothing to change on the existing row
+			return nil
+		}
+	}
+}
+
+// keyExists checks whether a row with the key exists after a failed insert
+func (c *Client) keyExists(table string, keyColumn string, key string) bool {
+	err := c.Execute("select * from " + table + " where " + keyColumn + " = " + quoteValue(key))
+	return err == nil && c.response.Rows > 0
+}
+
+// updateCommand composes the update of the row with the key, columns are set in sorted order
+func updateCommand(table string, keyColumn string, key string, changes map[string]string) (string, error) {
+	if !isIdentifier(table) {
+		return "", fmt.Errorf("Invalid table name: %q", table)
+	}
+	if !isIdentifier(keyColumn) {
+		return "", fmt.Errorf("Invalid column name: %q", keyColumn)
+	}
+	if len(changes) == 0 {
+		return "", fmt.Errorf("No columns to update in %s", table)
+	}
+	columns, values := sortedColumns(changes)
+	buffer := _BUILDERS.get()
+	defer _BUILDERS.put(buffer)
+	buffer.WriteString("update ")
+	buffer.WriteString(table)
+	buffer.WriteString(" set ")
+	for i, column := range columns {
+		if !isIdentifier(column) {
+			return "", fmt.Errorf("Invalid column name: %q", column)
+		}
+		if i > 0 {
+			buffer.WriteString(", ")
+		}
+		buffer.WriteString(column)
+		buffer.WriteString(" = ")
+		buffer.WriteString(quoteValue(values[i]))
+	}
+	buffer.WriteString(" where ")
+	buffer.WriteString(keyColumn)
+	buffer.WriteString(" = ")
+	buffer.WriteString(quoteValue(key))
+	if buffer.Len() > _MAX_COMMAND_SIZE {
+		return "", &CommandTooLargeError{Size: buffer.Len(), Limit: _MAX_COMMAND_SIZE}
+	}
+	return buffer.String(), nil
+}
+
+// sortedColumns returns the columns in sorted order with their values
+func sortedColumns(values map[string]string) ([]string, []string) {
+	columns := make([]string, 0, len(values))
+	for column := range values {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	row := make([]string, len(columns))
+	for i, column := range columns {
+		row[i] = values[column]
+	}
+	return columns, row
+}
+
+//ErrNotFound is returned by DeleteByKey and UpdateByKey when no row has the key.
+var ErrNotFound = errors.New("No row with the key")
+
+//SetKeyColumn sets the column DeleteByKey and UpdateByKey use to find rows of the table.
+//Executing a key command sets it as well; the built-in id column is used otherwise.
+func (c *Client) SetKeyColumn(table string, column string) {
+	if c.keyColumns == nil {
+		c.keyColumns = make(map[string]string)
+	}
+	c.keyColumns[table] = column
+}
+
+func (c *Client) keyColumn(table string) string {
+	if column, ok := c.keyColumns[table]; ok {
+		return column
+	}
+	return "id"
+}
+
+// trackKeys records the key column declared by a successfully executed key command
+func (c *Client) trackKeys(command string) {
+	if commandVerb(command) != "key" {
+		return
+	}
+	table, _, end := commandTable(command)
+	start, stop := nextWord(command, end)
+	if table != "" && start < stop {
+		c.SetKeyColumn(table, command[start:stop])
+	}
+}
+
+//DeleteByKey deletes the row of the table with the key.
+//ErrNotFound is returned when no row was deleted.
+func (c *Client) DeleteByKey(table string, key string) error {
+	column := c.keyColumn(table)
+	if !isIdentifier(table) {
+		return fmt.Errorf("Invalid table name: %q", table)
+	}
+	if !isIdentifier(column) {
+		return fmt.Errorf("Invalid column name: %q", column)
+	}
+	err := c.Execute("delete from " + table + " where " + column + " = " + quoteValue(key))
+	if err != nil {
+		return err
+	}
+	return c.verifyAffected()
+}
+
+//UpdateByKey sets the columns of the row of the table with the key to the values in changes.
+//ErrNotFound is returned when no row was updated.
+func (c *Client) UpdateByKey(table string, key string, changes map[string]string) error {
+	command, err := updateCommand(table, c.keyColumn(table), key, changes)
+	if err != nil {
+		return err
+	}
+	err = c.Execute(command)
+	if err != nil {
+		return err
+	}
+	return c.verifyAffected()
+}
+
+// verifyAffected checks that the last command changed exactly one row
+func (c *Client) verifyAffected() error {
+	switch {
+	case c.response.Rows == 0:
+		return ErrNotFound
+	case c.response.Rows > 1:
+		return fmt.Errorf("Key is not unique, %d rows affected", c.response.Rows)
+	}
+	return nil
+}
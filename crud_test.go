@@ -0,0 +1,110 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"errors"
+	. "gopkg.in/check.v1"
+	"strings"
+)
+
+func (s *TestSuite) TestInsertCommands(c *C) {
+	commands, err := InsertCommands("stocks", []string{"ticker", "name"}, [][]string{
+		{"IBM", "International Business Machines"},
+		{"MCD", "McDonald's"},
+		{"X", ""},
+	})
+	c.Assert(err, IsNil)
+	c.Assert(commands, DeepEquals, []string{
+		"insert into stocks (ticker,name) values (IBM,'International Business Machines')",
+		"insert into stocks (ticker,name) values (MCD,'McDonald''s')",
+		"insert into stocks (ticker,name) values (X,'')",
+	})
+
+	_, err = InsertCommands("stocks", []string{"ticker"}, [][]string{{"IBM", "extra"}})
+	c.Assert(err, ErrorMatches, "Row 0 has 2 values for 1 columns")
+	_, err = InsertCommands("stocks", []string{"bad column"}, nil)
+	c.Assert(err, NotNil)
+	_, err = InsertCommands("stocks", []string{"ticker"}, [][]string{{strings.Repeat("x", _MAX_COMMAND_SIZE)}})
+	c.Assert(err, ErrorMatches, "Row 0: Command of .* bytes exceeds the maximum command size.*")
+	c.Assert(errors.Is(err, ErrCommandTooLarge), Equals, true)
+}
+
+func (s *TestSuite) TestInsertRows(c *C) {
+	client := newPipeClient(
+		`{"status":"ok","action":"insert","id":"1"}`,
+		`{"status":"err","msg":"duplicate key"}`,
+	)
+	err := client.InsertRows("stocks", []string{"ticker"}, [][]string{{"IBM"}, {"IBM"}, {"MSFT"}})
+	c.Assert(err, ErrorMatches, "Row 1: .*duplicate key")
+}
+
+func (s *TestSuite) TestUpsert(c *C) {
+	client := newPipeClient(
+		`{"status":"ok","action":"update","rows":1}`,
+		`{"status":"ok","action":"update","rows":0}`,
+		`{"status":"ok","action":"insert","id":"1"}`,
+		// another client inserted the key after the update
+		`{"status":"ok","action":"update","rows":0}`,
+		`{"status":"err","msg":"duplicate key"}`,
+		`{"status":"ok","action":"select","rows":1,"fromrow":1,"torow":1,"columns":["ticker"],"data":[["IBM"]]}`,
+		`{"status":"ok","action":"update","rows":1}`,
+		// insert failing for another reason
+		`{"status":"ok","action":"update","rows":0}`,
+		`{"status":"err","msg":"no such column"}`,
+		`{"status":"ok","action":"select","rows":0,"fromrow":0,"torow":0}`,
+	)
+	values := map[string]string{"ticker": "IBM", "bid": "120"}
+	c.Assert(client.Upsert("stocks", "ticker", values), IsNil)
+	c.Assert(client.Action(), Equals, "update")
+	c.Assert(client.Upsert("stocks", "ticker", values), IsNil)
+	c.Assert(client.Action(), Equals, "insert")
+	c.Assert(client.Upsert("stocks", "ticker", values), IsNil)
+	c.Assert(client.Action(), Equals, "update")
+	c.Assert(client.Upsert("stocks", "ticker", values), ErrorMatches, ".*no such column")
+
+	c.Assert(client.Upsert("stocks", "ticker", map[string]string{"bid": "1"}), ErrorMatches, "Key column ticker is missing.*")
+}
+
+func (s *TestSuite) TestUpdateCommand(c *C) {
+	command, err := updateCommand("stocks", "ticker", "MCD", map[string]string{"name": "McDonald's", "bid": "12.5"})
+	c.Assert(err, IsNil)
+	c.Assert(command, Equals, "update stocks set bid = 12.5, name = 'McDonald''s' where ticker = MCD")
+	_, err = updateCommand("stocks", "ticker", "MCD", nil)
+	c.Assert(err, NotNil)
+	_, err = updateCommand("stocks", "ticker", "MCD", map[string]string{"a b": "1"})
+	c.Assert(err, NotNil)
+}
+
+func (s *TestSuite) TestByKey(c *C) {
+	client := newPipeClient(
+		`{"status":"ok","action":"delete","rows":1}`,
+		`{"status":"ok","action":"key"}`,
+		`{"status":"ok","action":"delete","rows":0}`,
+		`{"status":"ok","action":"update","rows":1}`,
+		`{"status":"ok","action":"update","rows":2}`,
+	)
+	observer := new(recordingObserver)
+	client.SetObserver(observer)
+	c.Assert(client.DeleteByKey("stocks", "7"), IsNil)
+	c.Assert(client.Execute("key stocks ticker"), IsNil)
+	c.Assert(client.DeleteByKey("stocks", "IBM"), Equals, ErrNotFound)
+	c.Assert(client.UpdateByKey("stocks", "Big Blue", map[string]string{"bid": "120"}), IsNil)
+	c.Assert(client.UpdateByKey("stocks", "IBM", map[string]string{"bid": "121"}), ErrorMatches, "Key is not unique.*")
+	c.Assert(observer.writes(), DeepEquals, []string{
+		"delete from stocks where id = 7",
+		"key stocks ticker",
+		"delete from stocks where ticker = IBM",
+		"update stocks set bid = 120 where ticker = 'Big Blue'",
+		"update stocks set bid = 121 where ticker = IBM",
+	})
+}
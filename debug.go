@@ -0,0 +1,44 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"sync/atomic"
+)
+
+//SetDebug enables or disables the debug mode.
+//In debug mode the Client panics on misuse that otherwise leads to undefined behavior:
+//calling Execute, NextRow or WaitForPubSub while another of them is in progress,
+//for example from a StateHandler, calling NextRow after Execute failed
+//and calling Value before NextRow moved to the first row.
+func (c *Client) SetDebug(debug bool) {
+	c.debug = debug
+}
+
+// enter marks the start of a blocking call and detects re-entrant use in debug mode
+func (c *Client) enter(name string) {
+	c.applyPending()
+	if c.debug && !atomic.CompareAndSwapInt32(&c.busy, 0, 1) {
+		panic("pubsubsql: " + name + " called while another call is in progress on the same Client")
+	}
+}
+
+func (c *Client) leave() {
+	atomic.StoreInt32(&c.busy, 0)
+}
+
+// misuse panics in debug mode
+func (c *Client) misuse(message string) {
+	if c.debug {
+		panic("pubsubsql: " + message)
+	}
+}
@@ -0,0 +1,45 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+func (s *TestSuite) TestDebugReentrancy(c *C) {
+	client := newPipeClient(`{"status":"ok"}`)
+	client.SetDebug(true)
+	client.SetStateHandler(func(connected bool, reason CloseReason) {
+		client.Execute("status")
+	})
+	c.Assert(client.Execute("status"), IsNil)
+	// the read error closes the connection and calls the handler from inside WaitForPubSub
+	c.Assert(func() { client.WaitForPubSub(1000) }, PanicMatches, ".*Execute called while another call is in progress.*")
+}
+
+func (s *TestSuite) TestDebugNextRowAfterFailure(c *C) {
+	client := newPipeClient(`{"status":"err","msg":"bad command"}`)
+	client.SetDebug(true)
+	c.Assert(client.Execute("bad"), NotNil)
+	c.Assert(func() { client.NextRow() }, PanicMatches, ".*NextRow called after Execute failed")
+}
+
+func (s *TestSuite) TestDebugValueBeforeNextRow(c *C) {
+	client := newPipeClient(`{"status":"ok","action":"select","rows":1,"fromrow":1,"torow":1,"columns":["id"],"data":[["1"]]}`)
+	client.SetDebug(true)
+	c.Assert(client.Execute("select * from stocks"), IsNil)
+	c.Assert(func() { client.Value("id") }, PanicMatches, ".*Value called before NextRow")
+	ok, err := client.NextRow()
+	c.Assert(ok, Equals, true)
+	c.Assert(err, IsNil)
+	c.Assert(client.Value("id"), Equals, "1")
+}
@@ -0,0 +1,85 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+//SetDedupColumn makes the Client add a unique dedup key to the given column of every streamed
+//insert and update command. Commands replayed by store and forward keep their key,
+//so consumers can drop duplicates with a Deduper reading the same column.
+//Pass an empty column to stop adding keys.
+func (c *Client) SetDedupColumn(column string) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	c.dedupColumn = column
+	if c.dedupPrefix == "" {
+		bytes := make([]byte, 6)
+		rand.Read(bytes)
+		c.dedupPrefix = hex.EncodeToString(bytes) + "x"
+	}
+}
+
+// dedupCommand adds the next dedup key to insert and update commands
+func (c *Client) dedupCommand(command string) string {
+	if c.dedupColumn == "" {
+		return command
+	}
+	key := c.dedupPrefix + strconv.FormatUint(atomic.AddUint64(&c.dedupSequence, 1), 10)
+	command, _ = addColumnValue(command, c.dedupColumn, key)
+	return command
+}
+
+//Deduper remembers the most recent dedup keys to detect messages delivered more than once.
+//It is safe for concurrent use, for example by the handlers of several consumers.
+type Deduper struct {
+	mu   sync.Mutex
+	seen map[string]bool
+	ring []string
+	next int
+}
+
+//NewDeduper returns a Deduper remembering up to capacity keys.
+func NewDeduper(capacity int) *Deduper {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &Deduper{
+		seen: make(map[string]bool, capacity),
+		ring: make([]string, capacity),
+	}
+}
+
+//Duplicate returns true if the key was seen before, otherwise it remembers the key and returns false.
+//Empty keys are never duplicates.
+func (d *Deduper) Duplicate(key string) bool {
+	if key == "" {
+		return false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.seen[key] {
+		return true
+	}
+	if old := d.ring[d.next]; old != "" {
+		delete(d.seen, old)
+	}
+	d.ring[d.next] = key
+	d.next = (d.next + 1) % len(d.ring)
+	d.seen[key] = true
+	return false
+}
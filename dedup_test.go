@@ -0,0 +1,74 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	. "gopkg.in/check.v1"
+	"sync"
+	"sync/atomic"
+)
+
+func (s *TestSuite) TestAddColumnValue(c *C) {
+	command, ok := addColumnValue("insert into stocks (ticker, bid) values ('I(B)M', 12)", "dedup", "k1")
+	c.Assert(ok, Equals, true)
+	c.Assert(command, Equals, "insert into stocks (ticker, bid, dedup) values ('I(B)M', 12, k1)")
+
+	command, ok = addColumnValue("update stocks set bid = 13 where ticker = IBM", "dedup", "k2")
+	c.Assert(ok, Equals, true)
+	c.Assert(command, Equals, "update stocks set bid = 13, dedup = k2 where ticker = IBM")
+
+	command, ok = addColumnValue("update stocks set bid = 13", "dedup", "k3")
+	c.Assert(ok, Equals, true)
+	c.Assert(command, Equals, "update stocks set bid = 13, dedup = k3")
+
+	_, ok = addColumnValue("delete from stocks", "dedup", "k4")
+	c.Assert(ok, Equals, false)
+}
+
+func (s *TestSuite) TestDeduper(c *C) {
+	d := NewDeduper(2)
+	c.Assert(d.Duplicate("a"), Equals, false)
+	c.Assert(d.Duplicate("a"), Equals, true)
+	c.Assert(d.Duplicate("b"), Equals, false)
+	c.Assert(d.Duplicate("c"), Equals, false)
+	// a was evicted
+	c.Assert(d.Duplicate("a"), Equals, false)
+	c.Assert(d.Duplicate(""), Equals, false)
+}
+
+func (s *TestSuite) TestDeduperConcurrent(c *C) {
+	d := NewDeduper(100)
+	var unique int32
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, key := range []string{"a", "b", "c"} {
+				if !d.Duplicate(key) {
+					atomic.AddInt32(&unique, 1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	c.Assert(unique, Equals, int32(3))
+}
+
+func (s *TestSuite) TestDedupCommand(c *C) {
+	client := new(Client)
+	client.SetDedupColumn("dedup")
+	first := client.dedupCommand("update stocks set bid = 1")
+	second := client.dedupCommand("update stocks set bid = 1")
+	c.Assert(first, Not(Equals), second)
+	c.Assert(first, Matches, "update stocks set bid = 1, dedup = [0-9a-f]{12}x1")
+}
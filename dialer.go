@@ -0,0 +1,29 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"context"
+	"net"
+)
+
+//Dialer opens a connection to the address returned by the Resolver, for example through a proxy,
+//from a specific interface or over an in-memory pipe in tests. network is always "tcp".
+type Dialer func(ctx context.Context, network, address string) (net.Conn, error)
+
+//SetDialer installs the dialer used by Connect instead of a net.Dialer.
+//The context passed to it expires after the connect timeout.
+//With SetTLSConfig the TLS handshake runs over the connection it returns.
+//Pass nil to restore the default.
+func (c *Client) SetDialer(dialer Dialer) {
+	c.dialer = dialer
+}
@@ -0,0 +1,47 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"context"
+	"errors"
+	. "gopkg.in/check.v1"
+	"net"
+)
+
+func (s *TestSuite) TestDialer(c *C) {
+	var dialed []string
+	client := new(Client)
+	client.SetDialer(func(ctx context.Context, network, address string) (net.Conn, error) {
+		_, ok := ctx.Deadline()
+		c.Assert(ok, Equals, true)
+		dialed = append(dialed, network+" "+address)
+		conn, server := net.Pipe()
+		go func() {
+			defer server.Close()
+			header, _, err := ReadFrame(server)
+			if err == nil {
+				WriteFrame(server, header.RequestId, []byte(`{"status":"ok","action":"status"}`))
+			}
+		}()
+		return conn, nil
+	})
+	c.Assert(client.Connect("pipe:7777"), IsNil)
+	c.Assert(client.Execute("status"), IsNil)
+	c.Assert(dialed, DeepEquals, []string{"tcp pipe:7777"})
+	client.Disconnect()
+
+	client.SetDialer(func(ctx context.Context, network, address string) (net.Conn, error) {
+		return nil, errors.New("No route")
+	})
+	c.Assert(client.Connect("pipe:7777"), ErrorMatches, "No route")
+}
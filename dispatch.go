@@ -0,0 +1,117 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+//MessageHandler processes a published message delivered by Dispatch.
+type MessageHandler func(message Message) error
+
+//DeadLetter is a message whose handler kept failing.
+type DeadLetter struct {
+	Message Message
+	//Attempts is the number of times the handler was called.
+	Attempts int
+	//Err is the error of the last attempt.
+	Err error
+}
+
+//DeadLetterSink receives the messages given up by Dispatch so they are not silently lost.
+type DeadLetterSink func(letter DeadLetter)
+
+//DispatchOptions configures Dispatch.
+type DispatchOptions struct {
+	//Retries is the number of times a failed message is handled again.
+	Retries int
+	//RetryDelay is the pause before every retry.
+	RetryDelay time.Duration
+	//DeadLetter receives the messages that failed every attempt, they are dropped when nil.
+	DeadLetter DeadLetterSink
+}
+
+//Dispatch calls the handler for every message received from the channel, for example one returned
+//by SubscribeChan, retrying failed messages and passing those that failed every attempt to the dead letter sink.
+//It returns when the channel is closed or with the error of ctx once it is done.
+func Dispatch(ctx context.Context, messages <-chan Message, handler MessageHandler, opts DispatchOptions) error {
+	for {
+		select {
+		case message, ok := <-messages:
+			if !ok {
+				return nil
+			}
+			if err := dispatch(ctx, message, handler, opts); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// dispatch handles one message with its retries
+func dispatch(ctx context.Context, message Message, handler MessageHandler, opts DispatchOptions) error {
+	var err error
+	attempts := 0
+	for attempts <= opts.Retries {
+		if attempts > 0 && opts.RetryDelay > 0 {
+			select {
+			case <-time.After(opts.RetryDelay):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		attempts++
+		if err = handler(message); err == nil {
+			return nil
+		}
+	}
+	if opts.DeadLetter != nil {
+		opts.DeadLetter(DeadLetter{Message: message, Attempts: attempts, Err: err})
+	}
+	return nil
+}
+
+//DeadLetterChannel returns a sink sending the dead letters on the channel, blocking while it is full.
+func DeadLetterChannel(letters chan<- DeadLetter) DeadLetterSink {
+	return func(letter DeadLetter) {
+		letters <- letter
+	}
+}
+
+//DeadLetterWriter returns a sink appending the dead letters to w as JSON lines holding the error,
+//the attempts, the pubsubid, the table and the original payload of the message.
+//Write errors are ignored.
+func DeadLetterWriter(w io.Writer) DeadLetterSink {
+	var mu sync.Mutex
+	return func(letter DeadLetter) {
+		line := struct {
+			Error    string          `json:"error"`
+			Attempts int             `json:"attempts"`
+			PubSubId string          `json:"pubsubid"`
+			Table    string          `json:"table,omitempty"`
+			Payload  json.RawMessage `json:"payload,omitempty"`
+		}{letter.Err.Error(), letter.Attempts, letter.Message.PubSubId, letter.Message.Table, letter.Message.Payload}
+		bytes, err := json.Marshal(line)
+		if err != nil {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		w.Write(append(bytes, '\n'))
+	}
+}
@@ -0,0 +1,59 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	. "gopkg.in/check.v1"
+)
+
+func (s *TestSuite) TestDispatchDeadLetter(c *C) {
+	client := newPipeClient(
+		`{"status":"ok","action":"subscribe","pubsubid":"1"}`,
+		`pubsub:{"status":"ok","action":"add","pubsubid":"1","rows":1,"fromrow":1,"torow":1,"columns":["ticker"],"data":[["IBM"]]}`,
+		`pubsub:{"status":"ok","action":"add","pubsubid":"1","rows":1,"fromrow":1,"torow":1,"columns":["ticker"],"data":[["BAD"]]}`,
+	)
+	messages, err := client.SubscribeChan("subscribe * from stocks")
+	c.Assert(err, IsNil)
+
+	attempts := make(map[string]int)
+	handler := func(message Message) error {
+		ticker := message.Value(0, "ticker")
+		attempts[ticker]++
+		if ticker == "BAD" {
+			return errors.New("unknown ticker")
+		}
+		return nil
+	}
+	letters := make(chan DeadLetter, 1)
+	var file bytes.Buffer
+	sinks := func(letter DeadLetter) {
+		DeadLetterChannel(letters)(letter)
+		DeadLetterWriter(&file)(letter)
+	}
+	// returns once the pipe server closes the connection after its script
+	err = Dispatch(context.Background(), messages, handler, DispatchOptions{Retries: 2, DeadLetter: sinks})
+	c.Assert(err, IsNil)
+	c.Assert(attempts, DeepEquals, map[string]int{"IBM": 1, "BAD": 3})
+
+	letter := <-letters
+	c.Assert(letter.Attempts, Equals, 3)
+	c.Assert(letter.Err, ErrorMatches, "unknown ticker")
+	c.Assert(letter.Message.Table, Equals, "stocks")
+	c.Assert(file.String(), Equals, `{"error":"unknown ticker","attempts":3,"pubsubid":"1","table":"stocks","payload":{"status":"ok","action":"add","pubsubid":"1","rows":1,"fromrow":1,"torow":1,"columns":["ticker"],"data":[["BAD"]]}}`+"\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c.Assert(Dispatch(ctx, make(chan Message), handler, DispatchOptions{}), Equals, context.Canceled)
+}
@@ -0,0 +1,181 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+//Package driver registers the pubsubsql database/sql driver, to run commands through the standard library:
+//
+//	import _ "github.com/pubsubsql/client/driver"
+//
+//	db, err := sql.Open("pubsubsql", "localhost:7777")
+//	rows, err := db.Query("select * from stocks where ticker = ?", "IBM")
+//
+//Arguments replace the ? placeholders outside quoted values. The pubsubsql server has no transactions
+//and every value is a string, so Begin fails and columns scan into strings or convertible types.
+//Subscriptions and published messages are only available on the native Client.
+package driver
+
+import (
+	"database/sql"
+	sqldriver "database/sql/driver"
+	"errors"
+	"io"
+	"net"
+
+	"github.com/pubsubsql/client"
+)
+
+//Name is the name the driver is registered with.
+const Name = "pubsubsql"
+
+func init() {
+	sql.Register(Name, &Driver{})
+}
+
+//Driver opens connections to the pubsubsql server, the data source name is the server address.
+type Driver struct{}
+
+//Open connects a new Client to the address.
+func (d *Driver) Open(address string) (sqldriver.Conn, error) {
+	client := new(pubsubsql.Client)
+	if err := client.Connect(address); err != nil {
+		return nil, err
+	}
+	return &conn{client: client}, nil
+}
+
+// conn executes the commands of one database/sql connection on its Client
+type conn struct {
+	client *pubsubsql.Client
+}
+
+func (this *conn) Prepare(query string) (sqldriver.Stmt, error) {
+	return &stmt{conn: this, query: query}, nil
+}
+
+func (this *conn) Close() error {
+	this.client.Disconnect()
+	return nil
+}
+
+func (this *conn) Begin() (sqldriver.Tx, error) {
+	return nil, errors.New("Transactions are not supported by the pubsubsql server")
+}
+
+func (this *conn) Exec(query string, args []sqldriver.Value) (sqldriver.Result, error) {
+	command, err := bind(query, args)
+	if err != nil {
+		return nil, err
+	}
+	if err = this.execute(command); err != nil {
+		return nil, err
+	}
+	return result(this.client.RowCount()), nil
+}
+
+func (this *conn) Query(query string, args []sqldriver.Value) (sqldriver.Rows, error) {
+	command, err := bind(query, args)
+	if err != nil {
+		return nil, err
+	}
+	if err = this.execute(command); err != nil {
+		return nil, err
+	}
+	return &rows{client: this.client, columns: append([]string(nil), this.client.Columns()...)}, nil
+}
+
+// execute runs the command, a connection that failed before the command was sent is reported as bad
+// so database/sql retries on a new one; once sent the command may have been executed and is not retried
+func (this *conn) execute(command string) error {
+	err := this.client.Execute(command)
+	if err != nil && !this.client.Connected() && unsent(err) {
+		return sqldriver.ErrBadConn
+	}
+	return err
+}
+
+// unsent returns true if the error shows that the command did not reach the connection
+func unsent(err error) bool {
+	if errors.Is(err, pubsubsql.ErrNotConnected) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// stmt is a command whose placeholders are bound on every execution
+type stmt struct {
+	conn  *conn
+	query string
+}
+
+func (this *stmt) Close() error {
+	return nil
+}
+
+func (this *stmt) NumInput() int {
+	return -1
+}
+
+func (this *stmt) Exec(args []sqldriver.Value) (sqldriver.Result, error) {
+	return this.conn.Exec(this.query, args)
+}
+
+func (this *stmt) Query(args []sqldriver.Value) (sqldriver.Rows, error) {
+	return this.conn.Query(this.query, args)
+}
+
+// result reports the rows affected, the pubsubsql server returns no generated ids
+type result int
+
+func (this result) LastInsertId() (int64, error) {
+	return 0, errors.New("LastInsertId is not supported by the pubsubsql server")
+}
+
+func (this result) RowsAffected() (int64, error) {
+	return int64(this), nil
+}
+
+// rows reads the result set of the last command with NextRow
+type rows struct {
+	client  *pubsubsql.Client
+	columns []string
+}
+
+func (this *rows) Columns() []string {
+	return this.columns
+}
+
+func (this *rows) Close() error {
+	// the remaining rows are skipped by the next command
+	return nil
+}
+
+func (this *rows) Next(dest []sqldriver.Value) error {
+	ok, err := this.client.NextRow()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return io.EOF
+	}
+	for i := range dest {
+		dest[i] = this.client.ValueByOrdinal(i)
+	}
+	return nil
+}
+
+// bind replaces the ? placeholders outside quoted values with the quoted arguments
+func bind(query string, args []sqldriver.Value) (string, error) {
+	values := make([]interface{}, len(args))
+	for i, arg := range args {
+		values[i] = arg
+	}
+	return pubsubsql.BindArgs(query, values...)
+}
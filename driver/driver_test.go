@@ -0,0 +1,136 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package driver
+
+import (
+	"database/sql"
+	sqldriver "database/sql/driver"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pubsubsql/client"
+	. "gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type TestSuite struct{}
+
+var _ = Suite(&TestSuite{})
+
+// scriptedServer returns the address of a server answering every request with the next response
+// and the channel receiving the requests
+func scriptedServer(c *C, responses ...string) (string, <-chan string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, IsNil)
+	requests := make(chan string, len(responses))
+	go func() {
+		defer listener.Close()
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for _, response := range responses {
+			header, message, err := pubsubsql.ReadFrame(conn)
+			if err != nil {
+				return
+			}
+			requests <- string(message)
+			pubsubsql.WriteFrame(conn, header.RequestId, []byte(response))
+		}
+	}()
+	return listener.Addr().String(), requests
+}
+
+func (s *TestSuite) TestQueryAndExec(c *C) {
+	address, requests := scriptedServer(c,
+		`{"status":"ok","action":"select","rows":2,"fromrow":1,"torow":2,"columns":["ticker","bid"],"data":[["IBM","140"],["MSFT","30.5"]]}`,
+		`{"status":"ok","action":"update","rows":1}`,
+	)
+	db, err := sql.Open(Name, address)
+	c.Assert(err, IsNil)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	rows, err := db.Query("select * from stocks where ticker != ?", "O'Neil")
+	c.Assert(err, IsNil)
+	c.Assert(<-requests, Equals, "select * from stocks where ticker != 'O''Neil'")
+	columns, err := rows.Columns()
+	c.Assert(err, IsNil)
+	c.Assert(columns, DeepEquals, []string{"ticker", "bid"})
+	var tickers []string
+	var total float64
+	for rows.Next() {
+		var ticker string
+		var bid float64
+		c.Assert(rows.Scan(&ticker, &bid), IsNil)
+		tickers = append(tickers, ticker)
+		total += bid
+	}
+	c.Assert(rows.Err(), IsNil)
+	c.Assert(tickers, DeepEquals, []string{"IBM", "MSFT"})
+	c.Assert(total, Equals, 170.5)
+
+	result, err := db.Exec("update stocks set bid = ? where ticker = '?'", 141)
+	c.Assert(err, IsNil)
+	c.Assert(<-requests, Equals, "update stocks set bid = 141 where ticker = '?'")
+	affected, err := result.RowsAffected()
+	c.Assert(err, IsNil)
+	c.Assert(affected, Equals, int64(1))
+
+	_, err = db.Begin()
+	c.Assert(err, ErrorMatches, "Transactions are not supported.*")
+}
+
+func (s *TestSuite) TestNoRetryAfterWrite(c *C) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, IsNil)
+	defer listener.Close()
+	requests := make(chan string, 10)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			// the command is read but the connection closes before the response
+			if _, message, err := pubsubsql.ReadFrame(conn); err == nil {
+				requests <- string(message)
+			}
+			conn.Close()
+		}
+	}()
+	db, err := sql.Open(Name, listener.Addr().String())
+	c.Assert(err, IsNil)
+	defer db.Close()
+	_, err = db.Exec("insert into stocks (ticker) values (?)", "IBM")
+	c.Assert(err, NotNil)
+	c.Assert(err, Not(Equals), sqldriver.ErrBadConn)
+	c.Assert(<-requests, Equals, "insert into stocks (ticker) values (IBM)")
+	select {
+	case request := <-requests:
+		c.Fatalf("command retried: %s", request)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func (s *TestSuite) TestBind(c *C) {
+	command, err := bind("insert into stocks (ticker, bid, open) values (?, ?, ?)", []sqldriver.Value{"IBM", 140.25, true})
+	c.Assert(err, IsNil)
+	c.Assert(command, Equals, "insert into stocks (ticker, bid, open) values (IBM, 140.25, true)")
+	_, err = bind("select * from stocks where ticker = ?", nil)
+	c.Assert(err, ErrorMatches, "Missing argument.*")
+	_, err = bind("select * from stocks", []sqldriver.Value{"IBM"})
+	c.Assert(err, ErrorMatches, "1 arguments for 0 placeholders")
+}
@@ -0,0 +1,169 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+//EngineConfig declares the connections, subscriptions and publishers of an Engine.
+type EngineConfig struct {
+	//Client configures every Client of the Engine.
+	Client ClientOptions
+	//Connections is the size of the Pool executing Query, 1 when zero.
+	Connections int
+	//Reconnect is passed to SetAutoReconnect of the Clients of the subscriptions and publishers.
+	Reconnect *ReconnectOptions
+	//Subscribe maps the subscribed tables to their options, which may be nil.
+	Subscribe map[string]*SubscribeOptions
+	//Publish maps the tables rows are published to with their Publisher.
+	Publish map[string]PublisherConfig
+}
+
+//PublisherConfig declares a Publisher of an Engine.
+type PublisherConfig struct {
+	Columns []string
+	Options PublisherOptions
+}
+
+//Engine wires together the Clients of a typical service from an EngineConfig:
+//a Pool for queries, one Client delivering the published messages of all subscriptions
+//with SubscribeChan and one Client per Publisher.
+//An Engine is safe for use by multiple goroutines.
+type Engine struct {
+	pool       *Pool
+	subscriber *Client
+	messages   map[string]<-chan Message
+	publishers map[string]*Publisher
+}
+
+//StartEngine validates the configuration, connects the Clients, subscribes to the tables
+//and creates the publishers. Everything started is closed again when a step fails.
+func StartEngine(ctx context.Context, config EngineConfig) (*Engine, error) {
+	if config.Connections == 0 {
+		config.Connections = 1
+	}
+	pool, err := NewPool(config.Client, config.Connections)
+	if err != nil {
+		return nil, err
+	}
+	e := &Engine{
+		pool:       pool,
+		messages:   make(map[string]<-chan Message),
+		publishers: make(map[string]*Publisher),
+	}
+	if err = e.start(ctx, config); err != nil {
+		e.Close(ctx)
+		return nil, err
+	}
+	return e, nil
+}
+
+func (e *Engine) start(ctx context.Context, config EngineConfig) error {
+	if len(config.Subscribe) > 0 {
+		client, err := e.connect(ctx, config)
+		if err != nil {
+			return err
+		}
+		e.subscriber = client
+		tables := make([]string, 0, len(config.Subscribe))
+		for table := range config.Subscribe {
+			tables = append(tables, table)
+		}
+		sort.Strings(tables)
+		for _, table := range tables {
+			command, err := subscribeCommand(table, config.Subscribe[table])
+			if err != nil {
+				return err
+			}
+			messages, err := client.SubscribeChan(command)
+			if err != nil {
+				return err
+			}
+			e.messages[table] = messages
+		}
+	}
+	tables := make([]string, 0, len(config.Publish))
+	for table := range config.Publish {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	for _, table := range tables {
+		client, err := e.connect(ctx, config)
+		if err != nil {
+			return err
+		}
+		publisher, err := NewPublisher(client, table, config.Publish[table].Columns, config.Publish[table].Options)
+		if err != nil {
+			client.Disconnect()
+			return fmt.Errorf("Publisher of %s: %v", table, err)
+		}
+		e.publishers[table] = publisher
+	}
+	return nil
+}
+
+// connect returns a new Client connected with the options of the configuration
+func (e *Engine) connect(ctx context.Context, config EngineConfig) (*Client, error) {
+	client := new(Client)
+	client.ApplyOptions(config.Client)
+	client.SetAutoReconnect(config.Reconnect)
+	err := client.ConnectContext(ctx, config.Client.Address)
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+//Query executes the command on a Client of the Pool and reads all rows of its result set.
+func (e *Engine) Query(ctx context.Context, command string) (*ResultSet, error) {
+	return e.pool.Query(ctx, command)
+}
+
+//Messages returns the channel receiving the published messages of the subscribed table
+//or nil when the table is not subscribed.
+func (e *Engine) Messages(table string) <-chan Message {
+	return e.messages[table]
+}
+
+//Publisher returns the Publisher of the table or nil when the table has none.
+func (e *Engine) Publisher(table string) *Publisher {
+	return e.publishers[table]
+}
+
+//Close closes the publishers, which streams their queued rows, then disconnects the subscriptions and the Pool.
+//The first error of a Publisher is returned.
+func (e *Engine) Close(ctx context.Context) error {
+	var first error
+	tables := make([]string, 0, len(e.publishers))
+	for table := range e.publishers {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	for _, table := range tables {
+		publisher := e.publishers[table]
+		if _, err := publisher.Close(ctx); err != nil && first == nil {
+			first = fmt.Errorf("Publisher of %s: %v", table, err)
+		}
+		publisher.client.Disconnect()
+	}
+	if e.subscriber != nil {
+		// the delivery goroutine closes the channels once it sees the subscriptions are gone
+		e.subscriber.pmu.Lock()
+		e.subscriber.Disconnect()
+		e.subscriber.pmu.Unlock()
+	}
+	e.pool.Close()
+	return first
+}
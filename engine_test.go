@@ -0,0 +1,63 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"context"
+	. "gopkg.in/check.v1"
+)
+
+func (s *TestSuite) TestEngine(c *C) {
+	address := serveConnections(c,
+		// subscriptions
+		[]string{
+			`{"status":"ok","action":"subscribe","pubsubid":"1"}`,
+			`pubsub:{"status":"ok","action":"add","pubsubid":"1","rows":1,"fromrow":1,"torow":1,"columns":["ticker"],"data":[["IBM"]]}`,
+		},
+		// publisher confirming on close
+		[]string{`{"status":"ok","action":"status"}`},
+		// pool
+		[]string{`{"status":"ok","action":"select","rows":1,"fromrow":1,"torow":1,"columns":["id"],"data":[["9"]]}`},
+	)
+	ctx := context.Background()
+	engine, err := StartEngine(ctx, EngineConfig{
+		Client:    ClientOptions{Address: address},
+		Subscribe: map[string]*SubscribeOptions{"stocks": nil},
+		Publish:   map[string]PublisherConfig{"orders": {Columns: []string{"id", "qty"}}},
+	})
+	c.Assert(err, IsNil)
+
+	message := receiveMessage(c, engine.Messages("stocks"))
+	c.Assert(message.Value(0, "ticker"), Equals, "IBM")
+	c.Assert(engine.Messages("orders"), IsNil)
+
+	publisher := engine.Publisher("orders")
+	c.Assert(publisher, NotNil)
+	dropped, err := publisher.Close(ctx)
+	c.Assert(err, IsNil)
+	c.Assert(dropped, Equals, 0)
+
+	result, err := engine.Query(ctx, "select * from orders")
+	c.Assert(err, IsNil)
+	c.Assert(result.Value(0, "id"), Equals, "9")
+	c.Assert(engine.Close(ctx), IsNil)
+}
+
+func (s *TestSuite) TestEngineInvalidConfig(c *C) {
+	_, err := StartEngine(context.Background(), EngineConfig{
+		Client:  ClientOptions{Address: "localhost:7777"},
+		Publish: map[string]PublisherConfig{"orders": {Columns: []string{"bad column"}}},
+	})
+	c.Assert(err, NotNil)
+	_, err = StartEngine(context.Background(), EngineConfig{})
+	c.Assert(err, ErrorMatches, "Invalid address.*")
+}
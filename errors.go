@@ -0,0 +1,106 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+//ErrNotConnected is returned when the Client is not connected to the pubsubsql server.
+var ErrNotConnected = errors.New("Not connected")
+
+//ErrTimeout is returned by WaitForPubSub when no message was published within the timeout.
+var ErrTimeout = errors.New("Timeout")
+
+//ErrReadTimeout is returned when the pubsubsql server did not respond within the read timeout,
+//the connection is closed.
+var ErrReadTimeout = errors.New("Read timed out")
+
+//ErrProtocol is wrapped by the errors returned when the pubsubsql server violates the protocol.
+var ErrProtocol = errors.New("protocol error")
+
+//ErrCommandTooLarge matches the CommandTooLargeError returned for commands the pubsubsql server would reject
+//because of their size, with errors.Is.
+var ErrCommandTooLarge = errors.New("Command too large")
+
+//CommandTooLargeError is returned before sending a command larger than the pubsubsql server accepts.
+//Insert many rows with InsertRows or InsertCommands, which compose one command per row.
+type CommandTooLargeError struct {
+	Size  int
+	Limit int
+}
+
+func (e *CommandTooLargeError) Error() string {
+	return fmt.Sprintf("Command of %d bytes exceeds the maximum command size of %d bytes", e.Size, e.Limit)
+}
+
+func (e *CommandTooLargeError) Is(target error) bool {
+	return target == ErrCommandTooLarge
+}
+
+// capacity of the asynchronous error channel
+const _ERRORS_CHANNEL_SIZE = 64
+
+//ResponseError is returned when the pubsubsql server rejects a command.
+type ResponseError struct {
+	//Message is the error message of the pubsubsql server.
+	Message string
+}
+
+func (e *ResponseError) Error() string {
+	return "response error: " + e.Message
+}
+
+//DroppedError reports a published message dropped because it exceeded the maximum age of its subscription
+//or did not fit into the backlog limited by RuntimeOptions.MaxBacklog.
+type DroppedError struct {
+	PubSubId string
+	Age      time.Duration
+}
+
+func (e *DroppedError) Error() string {
+	return fmt.Sprintf("Dropped message of subscription %s after %v", e.PubSubId, e.Age)
+}
+
+//CloseError reports a connection closed by a failure rather than by Disconnect.
+type CloseError struct {
+	Reason CloseReason
+}
+
+func (e *CloseError) Error() string {
+	return "Connection closed: " + e.Reason.String()
+}
+
+//Errors returns the channel delivering failures that do not surface through a direct call,
+//such as dropped messages, undecodable messages, streamed commands buffered after a write error
+//and connections closed by a failure.
+//Errors are discarded while the channel is full.
+func (c *Client) Errors() <-chan error {
+	return c.errorChannel()
+}
+
+func (c *Client) errorChannel() chan error {
+	c.errsOnce.Do(func() {
+		c.errs = make(chan error, _ERRORS_CHANNEL_SIZE)
+	})
+	return c.errs
+}
+
+// report delivers the error to the Errors channel without blocking
+func (c *Client) report(err error) {
+	select {
+	case c.errorChannel() <- err:
+	default:
+	}
+}
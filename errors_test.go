@@ -0,0 +1,75 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"errors"
+	. "gopkg.in/check.v1"
+	"time"
+)
+
+func (s *TestSuite) TestErrorsChannel(c *C) {
+	client := newPipeClient(
+		`{"status":"ok","action":"subscribe","pubsubid":"1"}`,
+		`pubsub:{"status":"ok","action":"add","pubsubid":"1","rows":1,"fromrow":1,"torow":1,"columns":["id"],"data":[["1"]]}`,
+		`{"status":"ok","action":"status"}`,
+	)
+	errs := client.Errors()
+	c.Assert(client.Execute("subscribe * from stocks"), IsNil)
+	client.Subscription("1").SetMaxAge(time.Millisecond)
+	c.Assert(client.Execute("status"), IsNil)
+	time.Sleep(5 * time.Millisecond)
+	c.Assert(client.WaitForPubSub(1000), NotNil)
+
+	dropped, ok := (<-errs).(*DroppedError)
+	c.Assert(ok, Equals, true)
+	c.Assert(dropped.PubSubId, Equals, "1")
+	closed, ok := (<-errs).(*CloseError)
+	c.Assert(ok, Equals, true)
+	c.Assert(closed.Reason, Equals, CloseServer)
+}
+
+func (s *TestSuite) TestErrorsChannelDisconnect(c *C) {
+	client := newPipeClient()
+	client.Disconnect()
+	select {
+	case err := <-client.Errors():
+		c.Fatalf("unexpected error %v", err)
+	default:
+	}
+}
+
+func (s *TestSuite) TestErrorsChannelFull(c *C) {
+	client := new(Client)
+	for i := 0; i < _ERRORS_CHANNEL_SIZE+1; i++ {
+		client.report(&CloseError{Reason: CloseWatchdog})
+	}
+	c.Assert(len(client.Errors()), Equals, _ERRORS_CHANNEL_SIZE)
+}
+
+func (s *TestSuite) TestErrorValues(c *C) {
+	client := newPipeClient(`{"status":"err","msg":"table does not exist"}`)
+	err := client.Execute("select * from missing")
+	var responseError *ResponseError
+	c.Assert(errors.As(err, &responseError), Equals, true)
+	c.Assert(responseError.Message, Equals, "table does not exist")
+	c.Assert(err.Error(), Equals, "response error: table does not exist")
+
+	client = new(Client)
+	c.Assert(errors.Is(client.Execute("status"), ErrNotConnected), Equals, true)
+	c.Assert(errors.Is(client.WaitForPubSub(1), ErrNotConnected), Equals, true)
+
+	client = newPipeClient(`{"status":"ok","action":"subscribe"}`)
+	_, err = client.Subscribe("stocks", nil)
+	c.Assert(errors.Is(err, ErrProtocol), Equals, true)
+	c.Assert(err, ErrorMatches, "protocol error missing pubsubid")
+}
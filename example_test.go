@@ -0,0 +1,125 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql_test
+
+import (
+	"fmt"
+
+	"github.com/pubsubsql/client"
+)
+
+// Code written against the bool returning API keeps working with LegacyClient.
+// Migrate call by call to the embedded Client, which returns errors,
+// then replace LegacyClient with Client.
+func ExampleLegacyClient() {
+	legacy := new(pubsubsql.LegacyClient)
+	// before: bool result and LastError() string
+	if !legacy.Connect("localhost:7777") {
+		fmt.Println("connect failed:", legacy.LastError())
+		return
+	}
+	defer legacy.Disconnect()
+	// after: the embedded Client returns an error
+	if err := legacy.Client.Execute("select * from stocks"); err != nil {
+		fmt.Println("select failed:", err)
+		return
+	}
+	for {
+		more, err := legacy.Client.NextRow()
+		if err != nil {
+			fmt.Println("next row failed:", err)
+			return
+		}
+		if !more {
+			break
+		}
+		fmt.Println(legacy.Value("ticker"))
+	}
+}
+
+func ExampleClient_Execute() {
+	client := new(pubsubsql.Client)
+	if err := client.Connect("localhost:7777"); err != nil {
+		fmt.Println("connect failed:", err)
+		return
+	}
+	defer client.Disconnect()
+
+	if err := client.Execute("insert into stocks (ticker, bid, ask) values (IBM, 123, 124)"); err != nil {
+		fmt.Println("insert failed:", err)
+		return
+	}
+	if err := client.Execute("select * from stocks where ticker = IBM"); err != nil {
+		fmt.Println("select failed:", err)
+		return
+	}
+	for {
+		more, err := client.NextRow()
+		if err != nil {
+			fmt.Println("next row failed:", err)
+			return
+		}
+		if !more {
+			break
+		}
+		fmt.Println(client.Value("ticker"), client.Value("bid"), client.Value("ask"))
+	}
+}
+
+func ExampleClient_Subscribe() {
+	client := new(pubsubsql.Client)
+	if err := client.Connect("localhost:7777"); err != nil {
+		fmt.Println("connect failed:", err)
+		return
+	}
+	defer client.Disconnect()
+
+	// receive only changes made from now on
+	sub, err := client.Subscribe("stocks", &pubsubsql.SubscribeOptions{Snapshot: pubsubsql.SkipSnapshot})
+	if err != nil {
+		fmt.Println("subscribe failed:", err)
+		return
+	}
+	for i := 0; i < 10; i++ {
+		if err := client.WaitForPubSub(1000); err != nil {
+			fmt.Println("no message:", err)
+			continue
+		}
+		for {
+			more, err := client.NextRow()
+			if err != nil || !more {
+				break
+			}
+			fmt.Println(client.PubSubTable(), client.Action(), client.Value("ticker"), client.Value("bid"))
+		}
+	}
+	sub.Pause()
+}
+
+// Producers that do not need a response use Stream, which is safe to call from many goroutines.
+func Example_streamQuotes() {
+	client := new(pubsubsql.Client)
+	if err := client.Connect("localhost:7777"); err != nil {
+		fmt.Println("connect failed:", err)
+		return
+	}
+	defer client.Disconnect()
+
+	quotes := map[string]string{"IBM": "124", "MSFT": "37", "ORCL": "39"}
+	for ticker, bid := range quotes {
+		command := fmt.Sprintf("update stocks set bid = %s where ticker = %s", bid, ticker)
+		if err := client.Stream(command); err != nil {
+			fmt.Println("stream failed:", err)
+			return
+		}
+	}
+}
@@ -0,0 +1,408 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+//Condition is a comparison of a where clause.
+type Condition struct {
+	Column   string
+	Operator string
+	Value    string
+}
+
+//Explanation is the parsed form of a command returned by Explain.
+type Explanation struct {
+	//Verb is the command, such as select or insert.
+	Verb string
+	//Stream is true for a streamed command.
+	Stream bool
+	//Skip is true for a subscribe command without the initial snapshot.
+	Skip bool
+	//Table is the table name as sent to the pubsubsql server, including the table prefix.
+	Table string
+	//Columns are the selected, inserted, updated or declared columns.
+	Columns []string
+	//Values are the unquoted inserted or updated values in the order of Columns.
+	Values []string
+	//Where holds the conditions of the where clause.
+	Where []Condition
+	//Rejected is the reason the Client would not send the command, if any.
+	Rejected string
+	//Notes point out details that often make a where clause match nothing.
+	Notes []string
+}
+
+//String formats the explanation one field per line.
+func (e *Explanation) String() string {
+	lines := []string{"verb: " + e.Verb}
+	if e.Stream {
+		lines = append(lines, "stream: true")
+	}
+	if e.Skip {
+		lines = append(lines, "skip snapshot: true")
+	}
+	if e.Table != "" {
+		lines = append(lines, "table: "+e.Table)
+	}
+	if len(e.Columns) > 0 {
+		lines = append(lines, "columns: "+strings.Join(e.Columns, ", "))
+	}
+	if len(e.Values) > 0 {
+		values := make([]string, len(e.Values))
+		for i, value := range e.Values {
+			values[i] = strconv.Quote(value)
+		}
+		lines = append(lines, "values: "+strings.Join(values, ", "))
+	}
+	for _, condition := range e.Where {
+		lines = append(lines, fmt.Sprintf("where: %s %s %q", condition.Column, condition.Operator, condition.Value))
+	}
+	if e.Rejected != "" {
+		lines = append(lines, "rejected: "+e.Rejected)
+	}
+	for _, note := range e.Notes {
+		lines = append(lines, "note: "+note)
+	}
+	return strings.Join(lines, "\n")
+}
+
+//Explain parses the command the way it would be sent by the Client, without sending it,
+//to show how the table, columns, values and where clause are understood.
+//The pubsubsql server has no explain command, so the command is only validated locally
+//and a command accepted by Explain may still be rejected by the pubsubsql server.
+func (c *Client) Explain(command string) (*Explanation, error) {
+	e, err := explain(prefixTable(command, c.tablePrefix))
+	if err != nil {
+		return nil, err
+	}
+	if err = c.checkCommand(command); err != nil {
+		e.Rejected = err.Error()
+	}
+	return e, nil
+}
+
+var _TWO_CHAR_OPERATORS = map[string]bool{"!=": true, "<=": true, ">=": true, "<>": true}
+
+// token of a command, quoted values are unescaped
+type token struct {
+	text   string
+	quoted bool
+	// position of the token in the command, including the quotes
+	start int
+	end   int
+}
+
+// tokenize splits the command into words, quoted values and punctuation
+func tokenize(command string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(command); {
+		ch := command[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\r' || ch == '\n':
+			i++
+		case ch == '\'':
+			start := i
+			var value []byte
+			closed := false
+			for i++; i < len(command); i++ {
+				if command[i] == '\'' {
+					if i+1 < len(command) && command[i+1] == '\'' {
+						value = append(value, '\'')
+						i++
+						continue
+					}
+					closed = true
+					i++
+					break
+				}
+				value = append(value, command[i])
+			}
+			if !closed {
+				return nil, fmt.Errorf("Unterminated quoted value: %q", command)
+			}
+			tokens = append(tokens, token{text: string(value), quoted: true, start: start, end: i})
+		case isWordChar(ch):
+			start := i
+			for i < len(command) && isWordChar(command[i]) {
+				i++
+			}
+			tokens = append(tokens, token{text: command[start:i], start: start, end: i})
+		default:
+			if i+1 < len(command) && _TWO_CHAR_OPERATORS[command[i:i+2]] {
+				tokens = append(tokens, token{text: command[i : i+2], start: i, end: i + 2})
+				i += 2
+				continue
+			}
+			tokens = append(tokens, token{text: command[i : i+1], start: i, end: i + 1})
+			i++
+		}
+	}
+	return tokens, nil
+}
+
+// parser walks the tokens of a command
+type parser struct {
+	tokens []token
+	next   int
+}
+
+func (this *parser) done() bool {
+	return this.next >= len(this.tokens)
+}
+
+func (this *parser) peek() string {
+	if this.done() {
+		return ""
+	}
+	return strings.ToLower(this.tokens[this.next].text)
+}
+
+func (this *parser) take() (token, error) {
+	if this.done() {
+		return token{}, fmt.Errorf("Unexpected end of command")
+	}
+	t := this.tokens[this.next]
+	this.next++
+	return t, nil
+}
+
+func (this *parser) expect(word string) error {
+	t, err := this.take()
+	if err != nil {
+		return fmt.Errorf("Expected %s at the end of the command", word)
+	}
+	if strings.ToLower(t.text) != word || t.quoted {
+		return fmt.Errorf("Expected %s but found %q", word, t.text)
+	}
+	return nil
+}
+
+func (this *parser) name(what string) (string, error) {
+	t, err := this.take()
+	if err != nil {
+		return "", fmt.Errorf("Expected %s at the end of the command", what)
+	}
+	if t.quoted || !isIdentifier(t.text) {
+		return "", fmt.Errorf("Expected %s but found %q", what, t.text)
+	}
+	return t.text, nil
+}
+
+func (this *parser) value() (string, error) {
+	t, err := this.take()
+	if err != nil {
+		return "", fmt.Errorf("Expected a value at the end of the command")
+	}
+	if !t.quoted && !isIdentifier(t.text) {
+		return "", fmt.Errorf("Expected a value but found %q", t.text)
+	}
+	return t.text, nil
+}
+
+// list parses a parenthesized comma separated list of names or values
+func (this *parser) list(item func() (string, error)) ([]string, error) {
+	if err := this.expect("("); err != nil {
+		return nil, err
+	}
+	var items []string
+	for {
+		text, err := item()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, text)
+		t, err := this.take()
+		if err != nil {
+			return nil, fmt.Errorf("Expected ) at the end of the command")
+		}
+		if t.text == ")" {
+			return items, nil
+		}
+		if t.text != "," {
+			return nil, fmt.Errorf("Expected , or ) but found %q", t.text)
+		}
+	}
+}
+
+func (this *parser) columns() ([]string, error) {
+	if this.peek() == "*" {
+		this.next++
+		return []string{"*"}, nil
+	}
+	var columns []string
+	for {
+		column, err := this.name("a column name")
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, column)
+		if this.peek() != "," {
+			return columns, nil
+		}
+		this.next++
+	}
+}
+
+func (this *parser) where(e *Explanation) error {
+	if this.done() {
+		return nil
+	}
+	if err := this.expect("where"); err != nil {
+		return err
+	}
+	for {
+		var condition Condition
+		var err error
+		if condition.Column, err = this.name("a column name"); err != nil {
+			return err
+		}
+		t, err := this.take()
+		if err != nil || t.quoted || isIdentifier(t.text) {
+			return fmt.Errorf("Expected a comparison after %s", condition.Column)
+		}
+		condition.Operator = t.text
+		if condition.Value, err = this.value(); err != nil {
+			return err
+		}
+		e.Where = append(e.Where, condition)
+		if this.done() {
+			return nil
+		}
+		if err = this.expect("and"); err != nil {
+			return err
+		}
+	}
+}
+
+// explain parses the command into its explanation
+func explain(command string) (*Explanation, error) {
+	tokens, err := tokenize(command)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	e := new(Explanation)
+	if p.peek() == "stream" {
+		e.Stream = true
+		p.next++
+	}
+	if p.done() {
+		return nil, fmt.Errorf("Empty command")
+	}
+	e.Verb = p.peek()
+	p.next++
+	switch e.Verb {
+	case "select", "subscribe":
+		if e.Verb == "subscribe" && p.peek() == "skip" {
+			e.Skip = true
+			p.next++
+		}
+		if e.Columns, err = p.columns(); err != nil {
+			return nil, err
+		}
+		fallthrough
+	case "delete", "unsubscribe":
+		if err = p.expect("from"); err != nil {
+			return nil, err
+		}
+		if e.Table, err = p.name("a table name"); err != nil {
+			return nil, err
+		}
+		err = p.where(e)
+	case "insert":
+		if err = p.expect("into"); err != nil {
+			return nil, err
+		}
+		if e.Table, err = p.name("a table name"); err != nil {
+			return nil, err
+		}
+		if e.Columns, err = p.list(func() (string, error) { return p.name("a column name") }); err != nil {
+			return nil, err
+		}
+		if err = p.expect("values"); err != nil {
+			return nil, err
+		}
+		if e.Values, err = p.list(p.value); err != nil {
+			return nil, err
+		}
+		if len(e.Values) != len(e.Columns) {
+			return nil, fmt.Errorf("%d values for %d columns", len(e.Values), len(e.Columns))
+		}
+		if !p.done() {
+			err = fmt.Errorf("Unexpected %q after the values", p.tokens[p.next].text)
+		}
+	case "update":
+		if e.Table, err = p.name("a table name"); err != nil {
+			return nil, err
+		}
+		if err = p.expect("set"); err != nil {
+			return nil, err
+		}
+		for {
+			column, err := p.name("a column name")
+			if err != nil {
+				return nil, err
+			}
+			if err = p.expect("="); err != nil {
+				return nil, err
+			}
+			value, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			e.Columns = append(e.Columns, column)
+			e.Values = append(e.Values, value)
+			if p.peek() != "," {
+				break
+			}
+			p.next++
+		}
+		err = p.where(e)
+	case "key", "tag":
+		if e.Table, err = p.name("a table name"); err != nil {
+			return nil, err
+		}
+		var column string
+		if column, err = p.name("a column name"); err != nil {
+			return nil, err
+		}
+		e.Columns = []string{column}
+	}
+	if err != nil {
+		return nil, err
+	}
+	e.addNotes()
+	return e, nil
+}
+
+// addNotes points out values that often explain an empty result
+func (e *Explanation) addNotes() {
+	for _, condition := range e.Where {
+		if condition.Operator != "=" {
+			e.Notes = append(e.Notes, fmt.Sprintf("operator %s on %s may not be supported by the pubsubsql server", condition.Operator, condition.Column))
+		}
+		if strings.TrimSpace(condition.Value) != condition.Value {
+			e.Notes = append(e.Notes, fmt.Sprintf("value of %s has leading or trailing spaces", condition.Column))
+		}
+		if condition.Value == "" {
+			e.Notes = append(e.Notes, fmt.Sprintf("value of %s is empty", condition.Column))
+		}
+	}
+	if len(e.Where) > 0 {
+		e.Notes = append(e.Notes, "values are compared as case-sensitive strings")
+	}
+}
@@ -0,0 +1,65 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+func (s *TestSuite) TestExplain(c *C) {
+	client := new(Client)
+	client.SetTablePrefix("t_")
+	client.SetReadOnly(true)
+
+	e, err := client.Explain("select ticker, bid from stocks where ticker = 'IBM ' and sector = tech")
+	c.Assert(err, IsNil)
+	c.Assert(e.Verb, Equals, "select")
+	c.Assert(e.Table, Equals, "t_stocks")
+	c.Assert(e.Columns, DeepEquals, []string{"ticker", "bid"})
+	c.Assert(e.Where, DeepEquals, []Condition{{"ticker", "=", "IBM "}, {"sector", "=", "tech"}})
+	c.Assert(e.Rejected, Equals, "")
+	c.Assert(e.String(), Equals, `verb: select
+table: t_stocks
+columns: ticker, bid
+where: ticker = "IBM "
+where: sector = "tech"
+note: value of ticker has leading or trailing spaces
+note: values are compared as case-sensitive strings`)
+
+	e, err = client.Explain("stream insert into stocks (ticker, name) values (MCD, 'McDonald''s')")
+	c.Assert(err, IsNil)
+	c.Assert(e.Stream, Equals, true)
+	c.Assert(e.Values, DeepEquals, []string{"MCD", "McDonald's"})
+	c.Assert(e.Rejected, Equals, ErrReadOnly.Error())
+
+	e, err = client.Explain("update stocks set bid = 12.5, ask = 13 where ticker = MCD")
+	c.Assert(err, IsNil)
+	c.Assert(e.Columns, DeepEquals, []string{"bid", "ask"})
+	c.Assert(e.Values, DeepEquals, []string{"12.5", "13"})
+
+	e, err = client.Explain("subscribe skip * from stocks")
+	c.Assert(err, IsNil)
+	c.Assert(e.Skip, Equals, true)
+	c.Assert(e.Columns, DeepEquals, []string{"*"})
+
+	for _, command := range []string{
+		"select * stocks",
+		"insert into stocks (ticker) values (IBM, 1)",
+		"select * from stocks where ticker",
+		"select * from stocks where ticker = 'IBM",
+		"update stocks bid = 1",
+		"",
+	} {
+		_, err = client.Explain(command)
+		c.Assert(err, NotNil, Commentf(command))
+	}
+}
@@ -0,0 +1,68 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+//RowFilter decides whether a row of the table is delivered to the application;
+//value returns the value of a column of the row or an empty string.
+type RowFilter func(table string, value func(column string) string) bool
+
+//AllowValues returns a RowFilter delivering only the rows whose column has one of the values,
+//rows without the column are withheld.
+func AllowValues(column string, values ...string) RowFilter {
+	allowed := make(map[string]bool, len(values))
+	for _, value := range values {
+		allowed[value] = true
+	}
+	return func(table string, value func(column string) string) bool {
+		return allowed[value(column)]
+	}
+}
+
+//SetRowFilter installs the filter applied to the rows of query results and published messages.
+//NextRow skips the withheld rows and WaitForPubSub skips published messages
+//all rows of which are withheld. RowCount still counts the withheld rows.
+//The filter runs in the client process and is no substitute for access control on the pubsubsql server.
+//Pass nil to remove the filter.
+func (c *Client) SetRowFilter(filter RowFilter) {
+	c.filter = filter
+}
+
+// filtered returns true when the current row is withheld by the row filter
+func (c *Client) filtered() bool {
+	if c.filter == nil {
+		return false
+	}
+	return !c.filter(c.rowTable(), c.Value)
+}
+
+// rowTable returns the table of the current response
+func (c *Client) rowTable() string {
+	if c.responseId == 0 {
+		return c.PubSubTable()
+	}
+	return c.responseTable
+}
+
+// withheld returns true when the published message has rows all of which are withheld,
+// messages continued in further batches are always delivered
+func (c *Client) withheld() bool {
+	if c.filter == nil || c.response.Rows == 0 || c.response.Fromrow == 0 || c.response.Torow != c.response.Rows {
+		return false
+	}
+	defer func() { c.record = -1 }()
+	for c.record = 0; c.record <= c.response.Torow-c.response.Fromrow; c.record++ {
+		if !c.filtered() {
+			return false
+		}
+	}
+	return true
+}
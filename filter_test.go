@@ -0,0 +1,60 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+func (s *TestSuite) TestRowFilterQuery(c *C) {
+	client := newPipeClient(
+		`{"status":"ok","action":"select","rows":3,"fromrow":1,"torow":3,"columns":["id","account"],"data":[["1","a"],["2","b"],["3","a"]]}`,
+	)
+	var tables []string
+	allow := AllowValues("account", "a")
+	client.SetRowFilter(func(table string, value func(string) string) bool {
+		tables = append(tables, table)
+		return allow(table, value)
+	})
+	c.Assert(client.Execute("select * from accounts"), IsNil)
+	var ids []string
+	for {
+		ok, err := client.NextRow()
+		c.Assert(err, IsNil)
+		if !ok {
+			break
+		}
+		ids = append(ids, client.Value("id"))
+	}
+	c.Assert(ids, DeepEquals, []string{"1", "3"})
+	c.Assert(tables, DeepEquals, []string{"accounts", "accounts", "accounts"})
+	c.Assert(client.RowCount(), Equals, 3)
+}
+
+func (s *TestSuite) TestRowFilterPubSub(c *C) {
+	client := newPipeClient(
+		`{"status":"ok","action":"subscribe","pubsubid":"1"}`,
+		`pubsub:{"status":"ok","action":"add","pubsubid":"1","rows":1,"fromrow":1,"torow":1,"columns":["id","account"],"data":[["1","b"]]}`,
+		`pubsub:{"status":"ok","action":"add","pubsubid":"1","rows":2,"fromrow":1,"torow":2,"columns":["id","account"],"data":[["2","b"],["3","a"]]}`,
+	)
+	client.SetRowFilter(AllowValues("account", "a"))
+	_, err := client.Subscribe("accounts", nil)
+	c.Assert(err, IsNil)
+	// the first message is withheld entirely
+	c.Assert(client.WaitForPubSub(1000), IsNil)
+	ok, err := client.NextRow()
+	c.Assert(ok, Equals, true)
+	c.Assert(client.Value("id"), Equals, "3")
+	ok, err = client.NextRow()
+	c.Assert(ok, Equals, false)
+	c.Assert(client.Subscription("1").Stats().Delivered, Equals, uint64(1))
+}
@@ -0,0 +1,186 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"fmt"
+	"strings"
+)
+
+var _WHERE_OPERATORS = map[string]bool{"=": true}
+
+//CommandBuilder composes a command from its parts with every name validated and every value quoted:
+//
+//	command, err := pubsubsql.Insert("stocks").Set("ticker", "IBM").Set("bid", "140").Command()
+//	command, err := pubsubsql.Select("stocks").Where("ticker", "=", "IBM").Command()
+//
+//The first invalid part is reported by Command.
+type CommandBuilder struct {
+	verb    string
+	table   string
+	skip    bool
+	columns []string
+	values  []string
+	where   []Condition
+	err     error
+}
+
+//Insert starts an insert command, columns and values are added with Set.
+func Insert(table string) *CommandBuilder {
+	return newCommandBuilder("insert", table)
+}
+
+//Update starts an update command, columns and values are added with Set.
+func Update(table string) *CommandBuilder {
+	return newCommandBuilder("update", table)
+}
+
+//Delete starts a delete command.
+func Delete(table string) *CommandBuilder {
+	return newCommandBuilder("delete", table)
+}
+
+//Select starts a select command of all columns unless Columns limits them.
+func Select(table string) *CommandBuilder {
+	return newCommandBuilder("select", table)
+}
+
+//Subscribe starts a subscribe command of all columns unless Columns limits them.
+func Subscribe(table string) *CommandBuilder {
+	return newCommandBuilder("subscribe", table)
+}
+
+//Unsubscribe starts an unsubscribe command.
+func Unsubscribe(table string) *CommandBuilder {
+	return newCommandBuilder("unsubscribe", table)
+}
+
+func newCommandBuilder(verb string, table string) *CommandBuilder {
+	b := &CommandBuilder{verb: verb, table: table}
+	if !isIdentifier(table) {
+		b.fail("Invalid table name: %q", table)
+	}
+	return b
+}
+
+// fail records the first error
+func (b *CommandBuilder) fail(format string, args ...interface{}) {
+	if b.err == nil {
+		b.err = fmt.Errorf(format, args...)
+	}
+}
+
+// column validates the column name
+func (b *CommandBuilder) column(column string) bool {
+	if !isIdentifier(column) {
+		b.fail("Invalid column name: %q", column)
+		return false
+	}
+	return true
+}
+
+//Set adds a column and its value to an insert or update command.
+func (b *CommandBuilder) Set(column string, value string) *CommandBuilder {
+	if b.verb != "insert" && b.verb != "update" {
+		b.fail("Set is not valid in a %s command", b.verb)
+	} else if b.column(column) {
+		b.columns = append(b.columns, column)
+		b.values = append(b.values, value)
+	}
+	return b
+}
+
+//Columns limits the columns of a select or subscribe command.
+func (b *CommandBuilder) Columns(columns ...string) *CommandBuilder {
+	if b.verb != "select" && b.verb != "subscribe" {
+		b.fail("Columns is not valid in a %s command", b.verb)
+		return b
+	}
+	for _, column := range columns {
+		if b.column(column) {
+			b.columns = append(b.columns, column)
+		}
+	}
+	return b
+}
+
+//Skip makes a subscribe command skip the initial snapshot.
+func (b *CommandBuilder) Skip() *CommandBuilder {
+	if b.verb != "subscribe" {
+		b.fail("Skip is not valid in a %s command", b.verb)
+	}
+	b.skip = true
+	return b
+}
+
+//Where sets the condition of the command. The pubsubsql server accepts a single condition
+//with the = operator, other operators and a second condition are reported by Command.
+func (b *CommandBuilder) Where(column string, operator string, value string) *CommandBuilder {
+	switch {
+	case b.verb == "insert":
+		b.fail("Where is not valid in an insert command")
+	case len(b.where) > 0:
+		b.fail("Only one where condition is supported by the pubsubsql server")
+	case !_WHERE_OPERATORS[operator]:
+		b.fail("Invalid operator: %q", operator)
+	case b.column(column):
+		b.where = append(b.where, Condition{Column: column, Operator: operator, Value: value})
+	}
+	return b
+}
+
+//Command returns the composed command or the first invalid part.
+func (b *CommandBuilder) Command() (string, error) {
+	if b.err != nil {
+		return "", b.err
+	}
+	if (b.verb == "insert" || b.verb == "update") && len(b.columns) == 0 {
+		return "", fmt.Errorf("No columns set in the %s command", b.verb)
+	}
+	buffer := _BUILDERS.get()
+	defer _BUILDERS.put(buffer)
+	buffer.WriteString(b.verb)
+	switch b.verb {
+	case "insert":
+		buffer.WriteString(" into " + b.table + " (" + strings.Join(b.columns, ", ") + ") values (")
+		for i, value := range b.values {
+			if i > 0 {
+				buffer.WriteString(", ")
+			}
+			buffer.WriteString(quoteValue(value))
+		}
+		buffer.WriteString(")")
+	case "update":
+		buffer.WriteString(" " + b.table + " set ")
+		for i, column := range b.columns {
+			if i > 0 {
+				buffer.WriteString(", ")
+			}
+			buffer.WriteString(column + " = " + quoteValue(b.values[i]))
+		}
+	case "select", "subscribe":
+		if b.skip {
+			buffer.WriteString(" skip")
+		}
+		columns := "*"
+		if len(b.columns) > 0 {
+			columns = strings.Join(b.columns, ", ")
+		}
+		buffer.WriteString(" " + columns + " from " + b.table)
+	default:
+		buffer.WriteString(" from " + b.table)
+	}
+	for _, condition := range b.where {
+		buffer.WriteString(" where " + condition.Column + " " + condition.Operator + " " + quoteValue(condition.Value))
+	}
+	return buffer.String(), nil
+}
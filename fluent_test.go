@@ -0,0 +1,56 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+func (s *TestSuite) TestCommandBuilder(c *C) {
+	commands := []struct {
+		builder *CommandBuilder
+		command string
+	}{
+		{Insert("stocks").Set("ticker", "IBM").Set("name", "O'Neil Corp"), "insert into stocks (ticker, name) values (IBM, 'O''Neil Corp')"},
+		{Update("stocks").Set("bid", "140.5").Where("ticker", "=", "IBM"), "update stocks set bid = 140.5 where ticker = IBM"},
+		{Delete("stocks").Where("bid", "=", ""), "delete from stocks where bid = ''"},
+		{Select("stocks"), "select * from stocks"},
+		{Subscribe("stocks").Skip().Columns("ticker", "bid").Where("ticker", "=", "IBM"), "subscribe skip ticker, bid from stocks where ticker = IBM"},
+		{Unsubscribe("stocks"), "unsubscribe from stocks"},
+	}
+	for _, t := range commands {
+		command, err := t.builder.Command()
+		c.Assert(err, IsNil)
+		c.Assert(command, Equals, t.command)
+		_, err = explain(command)
+		c.Assert(err, IsNil)
+	}
+
+	errors := []struct {
+		builder *CommandBuilder
+		err     string
+	}{
+		{Insert("stocks where"), "Invalid table name.*"},
+		{Insert("stocks"), "No columns set in the insert command"},
+		{Select("stocks").Set("bid", "1"), "Set is not valid in a select command"},
+		{Update("stocks").Set("bid,ask", "1"), "Invalid column name.*"},
+		{Insert("stocks").Set("bid", "1").Where("ticker", "=", "IBM"), "Where is not valid in an insert command"},
+		{Select("stocks").Where("ticker", "like", "I%"), "Invalid operator.*"},
+		{Select("stocks").Where("bid", "<", "10"), "Invalid operator.*"},
+		{Delete("stocks").Where("ticker", "=", "IBM").Where("bid", "=", "1"), "Only one where condition.*"},
+		{Delete("stocks").Skip(), "Skip is not valid in a delete command"},
+	}
+	for _, t := range errors {
+		_, err := t.builder.Command()
+		c.Assert(err, ErrorMatches, t.err)
+	}
+}
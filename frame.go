@@ -0,0 +1,48 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"io"
+)
+
+//WriteFrame writes the message with its header to w in a single write,
+//for proxies, sniffers and test doubles speaking the pubsubsql wire format.
+//Concurrent writers must synchronize their calls.
+func WriteFrame(w io.Writer, requestId uint32, message []byte) error {
+	frame := make([]byte, HeaderSize+len(message))
+	NewHeader(uint32(len(message)), requestId).Encode(frame)
+	copy(frame[HeaderSize:], message)
+	_, err := w.Write(frame)
+	return err
+}
+
+//ReadFrame reads the next header and message from r.
+//io.EOF is returned when r ends before a frame starts, io.ErrUnexpectedEOF when it ends within a frame.
+func ReadFrame(r io.Reader) (*Header, []byte, error) {
+	bytes := make([]byte, HeaderSize)
+	_, err := io.ReadFull(r, bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	header := new(Header)
+	header.Decode(bytes)
+	message := make([]byte, header.MessageSize)
+	_, err = io.ReadFull(r, message)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return header, message, nil
+}
@@ -0,0 +1,41 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"bytes"
+	. "gopkg.in/check.v1"
+	"io"
+)
+
+func (s *TestSuite) TestFrames(c *C) {
+	var buffer bytes.Buffer
+	c.Assert(WriteFrame(&buffer, 7, []byte("status")), IsNil)
+	c.Assert(WriteFrame(&buffer, 0, nil), IsNil)
+	c.Assert(buffer.Len(), Equals, 2*HeaderSize+len("status"))
+
+	header, message, err := ReadFrame(&buffer)
+	c.Assert(err, IsNil)
+	c.Assert(*header, Equals, Header{MessageSize: 6, RequestId: 7})
+	c.Assert(string(message), Equals, "status")
+	header, message, err = ReadFrame(&buffer)
+	c.Assert(err, IsNil)
+	c.Assert(header.RequestId, Equals, uint32(0))
+	c.Assert(message, HasLen, 0)
+	_, _, err = ReadFrame(&buffer)
+	c.Assert(err, Equals, io.EOF)
+
+	WriteFrame(&buffer, 1, []byte("truncated"))
+	buffer.Truncate(HeaderSize + 3)
+	_, _, err = ReadFrame(&buffer)
+	c.Assert(err, Equals, io.ErrUnexpectedEOF)
+}
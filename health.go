@@ -0,0 +1,93 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"time"
+)
+
+var _MAX_READ_TIMEOUT = 3 * time.Minute
+
+// cadence learns the interval between frames received from the server
+type cadence struct {
+	enabled    bool
+	multiplier float64
+	minTimeout time.Duration
+	// fixed read timeout and upper bound of the adaptive one, zero means _MAX_READ_TIMEOUT
+	maxTimeout time.Duration
+	// moving average of the interval between frames
+	interval  time.Duration
+	lastFrame time.Time
+}
+
+func (this *cadence) observe(now time.Time) {
+	if !this.lastFrame.IsZero() {
+		gap := now.Sub(this.lastFrame)
+		if gap < 0 {
+			gap = 0
+		}
+		if this.interval == 0 {
+			this.interval = gap
+		} else {
+			this.interval = (this.interval*7 + gap) / 8
+		}
+	}
+	this.lastFrame = now
+}
+
+// timeout returns the read timeout derived from the observed interval
+func (this *cadence) timeout() time.Duration {
+	max := this.maxTimeout
+	if max == 0 {
+		max = _MAX_READ_TIMEOUT
+	}
+	if !this.enabled || this.interval == 0 {
+		return max
+	}
+	timeout := time.Duration(float64(this.interval) * this.multiplier)
+	if timeout < this.minTimeout {
+		timeout = this.minTimeout
+	}
+	if timeout > max {
+		timeout = max
+	}
+	return timeout
+}
+
+//SetReadTimeout sets the maximum time to wait for a response from the pubsubsql server,
+//after which the connection is closed and the command fails with ErrReadTimeout.
+//It also bounds the adaptive read timeout. Zero restores the default of 3 minutes.
+func (c *Client) SetReadTimeout(timeout time.Duration) {
+	c.cadence.maxTimeout = timeout
+}
+
+//SetAdaptiveReadTimeout replaces the fixed read timeout with multiplier times the
+//observed interval between messages from the pubsubsql server, but not less than minTimeout.
+//Healthy reports false once nothing was received for that long.
+//A multiplier of zero restores the fixed read timeout.
+func (c *Client) SetAdaptiveReadTimeout(multiplier float64, minTimeout time.Duration) {
+	c.cadence.enabled = multiplier > 0
+	c.cadence.multiplier = multiplier
+	c.cadence.minTimeout = minTimeout
+}
+
+//Healthy returns true if the Client is connected and, with an adaptive read timeout,
+//the pubsubsql server sent a message within the timeout.
+func (c *Client) Healthy() bool {
+	if !c.Connected() {
+		return false
+	}
+	if !c.cadence.enabled || c.cadence.lastFrame.IsZero() {
+		return true
+	}
+	return since(c.cadence.lastFrame) <= c.cadence.timeout()
+}
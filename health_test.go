@@ -0,0 +1,55 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	. "gopkg.in/check.v1"
+	"time"
+)
+
+func (s *TestSuite) TestCadenceTimeout(c *C) {
+	var cadence cadence
+	c.Assert(cadence.timeout(), Equals, _MAX_READ_TIMEOUT)
+	cadence.enabled = true
+	cadence.multiplier = 3
+	cadence.minTimeout = time.Second
+	start := time.Now()
+	cadence.observe(start)
+	cadence.observe(start.Add(2 * time.Second))
+	c.Assert(cadence.timeout(), Equals, 6*time.Second)
+	cadence.observe(start.Add(2*time.Second + 10*time.Millisecond))
+	c.Assert(cadence.interval < 2*time.Second, Equals, true)
+	cadence.interval = time.Millisecond
+	c.Assert(cadence.timeout(), Equals, time.Second)
+	cadence.interval = time.Hour
+	c.Assert(cadence.timeout(), Equals, _MAX_READ_TIMEOUT)
+	cadence.maxTimeout = 4 * time.Second
+	c.Assert(cadence.timeout(), Equals, 4*time.Second)
+	cadence.enabled = false
+	c.Assert(cadence.timeout(), Equals, 4*time.Second)
+}
+
+func (s *TestSuite) TestReadTimeout(c *C) {
+	client, _ := newCaptureClient()
+	client.SetReadTimeout(20 * time.Millisecond)
+	c.Assert(client.Execute("status"), Equals, ErrReadTimeout)
+}
+
+func (s *TestSuite) TestHealthy(c *C) {
+	client := newPipeClient(`{"status":"ok"}`, `{"status":"ok"}`)
+	client.SetAdaptiveReadTimeout(2, 10*time.Millisecond)
+	c.Assert(client.Execute("status"), IsNil)
+	c.Assert(client.Execute("status"), IsNil)
+	c.Assert(client.Healthy(), Equals, true)
+	time.Sleep(50 * time.Millisecond)
+	c.Assert(client.Healthy(), Equals, false)
+}
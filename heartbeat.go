@@ -0,0 +1,85 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"time"
+)
+
+//StaleHandler is called by CheckHeartbeats for a subscription that received no message within its heartbeat interval.
+type StaleHandler func(s *Subscription)
+
+//ExpectHeartbeat declares that the subscription receives at least one published message every interval,
+//for tables updated periodically, for example by a heartbeat row the producer updates.
+//The pubsubsql server does not notify the Client when it loses a subscription,
+//a missing heartbeat is the only sign of it.
+//CheckHeartbeats marks the subscription stale once the interval passed without a message
+//and calls handler, or resubscribes when handler is nil. Zero removes the expectation.
+func (s *Subscription) ExpectHeartbeat(interval time.Duration, handler StaleHandler) {
+	s.heartbeat = interval
+	s.onStale = handler
+}
+
+//Stale returns true if the subscription was marked stale by CheckHeartbeats and received no message since.
+func (s *Subscription) Stale() bool {
+	return s.stale
+}
+
+//Resubscribe replaces the subscription on the pubsubsql server by unsubscribing and executing
+//the subscribe command again. A rejected unsubscribe is ignored since the pubsubsql server
+//may have lost the subscription. The PubSubId changes like when a paused subscription is resumed.
+func (s *Subscription) Resubscribe() error {
+	s.client.pmu.Lock()
+	defer s.client.pmu.Unlock()
+	if s.paused {
+		return s.resume()
+	}
+	err := s.unsubscribe()
+	if _, rejected := err.(*ResponseError); rejected {
+		delete(s.client.subscriptions, s.pubsubId)
+	} else if err != nil {
+		return err
+	}
+	return s.subscribe()
+}
+
+//CheckHeartbeats marks the subscriptions that missed their heartbeat as stale and calls their StaleHandler
+//or resubscribes them, returning the first error of a resubscribe.
+//Call it periodically, for example whenever WaitForPubSub times out.
+func (c *Client) CheckHeartbeats() error {
+	var stale []*Subscription
+	for _, s := range c.subscriptions {
+		if s.heartbeat > 0 && since(s.heard) > s.heartbeat {
+			stale = append(stale, s)
+		}
+	}
+	var first error
+	for _, s := range stale {
+		s.stale = true
+		if s.onStale != nil {
+			s.onStale(s)
+			continue
+		}
+		if err := s.Resubscribe(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// heard notes a message received for the subscription of the current message
+func (c *Client) heard(received time.Time) {
+	if s := c.subscriptions[c.response.PubSubId]; s != nil {
+		s.heard = received
+		s.stale = false
+	}
+}
@@ -0,0 +1,57 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	. "gopkg.in/check.v1"
+	"time"
+)
+
+func (s *TestSuite) TestHeartbeatResubscribe(c *C) {
+	client := newPipeClient(
+		`{"status":"ok","action":"subscribe","pubsubid":"1"}`,
+		`{"status":"err","msg":"pubsubid does not exist"}`,
+		`{"status":"ok","action":"subscribe","pubsubid":"2"}`,
+	)
+	sub, err := client.Subscribe("heartbeats", nil)
+	c.Assert(err, IsNil)
+	sub.ExpectHeartbeat(time.Millisecond, nil)
+	c.Assert(client.CheckHeartbeats(), IsNil)
+	c.Assert(sub.Stale(), Equals, false)
+
+	time.Sleep(5 * time.Millisecond)
+	c.Assert(client.CheckHeartbeats(), IsNil)
+	c.Assert(sub.Stale(), Equals, false)
+	c.Assert(sub.PubSubId(), Equals, "2")
+	c.Assert(client.Subscription("1"), IsNil)
+	c.Assert(client.Subscription("2"), Equals, sub)
+}
+
+func (s *TestSuite) TestHeartbeatHandler(c *C) {
+	client := newPipeClient(
+		`{"status":"ok","action":"subscribe","pubsubid":"1"}`,
+		`pubsub:{"status":"ok","action":"update","pubsubid":"1"}`,
+	)
+	sub, err := client.Subscribe("heartbeats", nil)
+	c.Assert(err, IsNil)
+	var stale []*Subscription
+	sub.ExpectHeartbeat(time.Millisecond, func(s *Subscription) {
+		stale = append(stale, s)
+	})
+	time.Sleep(5 * time.Millisecond)
+	c.Assert(client.CheckHeartbeats(), IsNil)
+	c.Assert(stale, DeepEquals, []*Subscription{sub})
+	c.Assert(sub.Stale(), Equals, true)
+
+	c.Assert(client.WaitForPubSub(1000), IsNil)
+	c.Assert(sub.Stale(), Equals, false)
+}
@@ -0,0 +1,69 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+// history keeps copies of the last raw responses in a ring
+type history struct {
+	responses []string
+	next      int
+	full      bool
+}
+
+func (this *history) add(bytes []byte) {
+	this.responses[this.next] = string(bytes)
+	this.next++
+	if this.next == len(this.responses) {
+		this.next = 0
+		this.full = true
+	}
+}
+
+// all returns the responses oldest first
+func (this *history) all() []string {
+	if !this.full {
+		return append([]string(nil), this.responses[:this.next]...)
+	}
+	return append(append([]string(nil), this.responses[this.next:]...), this.responses[:this.next]...)
+}
+
+//SetResponseHistory makes the Client keep copies of the last size raw JSON responses,
+//including published messages and responses that failed to decode, for RecentResponses.
+//Zero, the default, disables the history and releases the kept responses.
+func (c *Client) SetResponseHistory(size int) {
+	c.smu.Lock()
+	defer c.smu.Unlock()
+	if size <= 0 {
+		c.history = nil
+		return
+	}
+	c.history = &history{responses: make([]string, size)}
+}
+
+//RecentResponses returns the kept raw JSON responses, oldest first.
+//It may be called from any goroutine, also while another one waits for responses.
+func (c *Client) RecentResponses() []string {
+	c.smu.Lock()
+	defer c.smu.Unlock()
+	if c.history == nil {
+		return nil
+	}
+	return c.history.all()
+}
+
+// remember keeps a copy of the response when the history is enabled
+func (c *Client) remember(bytes []byte) {
+	c.smu.Lock()
+	defer c.smu.Unlock()
+	if c.history != nil {
+		c.history.add(c.redactResponse(bytes))
+	}
+}
@@ -0,0 +1,59 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+func (s *TestSuite) TestResponseHistory(c *C) {
+	client := newPipeClient(
+		`{"status":"ok","action":"status","id":"1"}`,
+		`{"status":"ok","action":"status","id":"2"}`,
+		`not json`,
+		`{"status":"ok","action":"status","id":"4"}`,
+	)
+	c.Assert(client.RecentResponses(), IsNil)
+	client.SetResponseHistory(2)
+	c.Assert(client.Execute("status"), IsNil)
+	c.Assert(client.RecentResponses(), DeepEquals, []string{`{"status":"ok","action":"status","id":"1"}`})
+	c.Assert(client.Execute("status"), IsNil)
+	c.Assert(client.Execute("status"), NotNil)
+	c.Assert(client.RecentResponses(), DeepEquals, []string{`{"status":"ok","action":"status","id":"2"}`, `not json`})
+	client.SetResponseHistory(0)
+	c.Assert(client.Execute("status"), IsNil)
+	c.Assert(client.RecentResponses(), IsNil)
+}
+
+func (s *TestSuite) TestResponseHistoryWhileWaiting(c *C) {
+	address := serveConnections(c, []string{
+		`{"status":"ok","action":"subscribe","pubsubid":"1"}`,
+		`pubsub:{"status":"ok","action":"add","pubsubid":"1"}`,
+		`pubsub:{"status":"ok","action":"add","pubsubid":"1"}`,
+	})
+	client := new(Client)
+	c.Assert(client.Connect(address), IsNil)
+	c.Assert(client.Execute("subscribe * from stocks"), IsNil)
+	done := make(chan bool)
+	go func() {
+		for i := 0; i < 2; i++ {
+			client.WaitForPubSub(1000)
+		}
+		close(done)
+	}()
+	for size := 1; size < 100; size++ {
+		client.SetResponseHistory(size)
+		client.RecentResponses()
+	}
+	// run with -race, the reading goroutine records the messages meanwhile
+	<-done
+}
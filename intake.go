@@ -0,0 +1,66 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"time"
+)
+
+//PauseIntake stops WaitForPubSub from reading published messages until ResumeIntake is called,
+//for short maintenance operations of the application. The messages stay in the socket buffers
+//and TCP backpressure eventually slows down the pubsubsql server, so the subscriptions are kept
+//without unsubscribing. Meanwhile WaitForPubSub waits for ResumeIntake or its timeout.
+//Commands still read their responses and keep the published messages arriving before them in the backlog.
+//PauseIntake and ResumeIntake may be called from any goroutine.
+func (c *Client) PauseIntake() {
+	c.imu.Lock()
+	defer c.imu.Unlock()
+	if c.resumed == nil {
+		c.resumed = make(chan struct{})
+	}
+}
+
+//ResumeIntake lets WaitForPubSub read published messages again.
+func (c *Client) ResumeIntake() {
+	c.imu.Lock()
+	defer c.imu.Unlock()
+	if c.resumed != nil {
+		close(c.resumed)
+		c.resumed = nil
+	}
+}
+
+//IntakePaused returns true between PauseIntake and ResumeIntake.
+func (c *Client) IntakePaused() bool {
+	c.imu.Lock()
+	defer c.imu.Unlock()
+	return c.resumed != nil
+}
+
+// waitIntake waits up to timeout milliseconds while the intake is paused,
+// false is returned when it is still paused
+func (c *Client) waitIntake(timeout int) bool {
+	c.imu.Lock()
+	resumed := c.resumed
+	c.imu.Unlock()
+	if resumed == nil {
+		return true
+	}
+	timer := time.NewTimer(time.Duration(timeout) * time.Millisecond)
+	defer timer.Stop()
+	select {
+	case <-resumed:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
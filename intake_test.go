@@ -0,0 +1,37 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	. "gopkg.in/check.v1"
+	"time"
+)
+
+func (s *TestSuite) TestPauseIntake(c *C) {
+	client := newPipeClient(
+		`{"status":"ok","action":"subscribe","pubsubid":"1"}`,
+		`pubsub:{"status":"ok","action":"add","pubsubid":"1"}`,
+	)
+	c.Assert(client.Execute("subscribe * from stocks"), IsNil)
+	client.PauseIntake()
+	c.Assert(client.IntakePaused(), Equals, true)
+	c.Assert(client.WaitForPubSub(10), Equals, ErrTimeout)
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		client.ResumeIntake()
+	}()
+	// resuming wakes the waiting call before its timeout
+	c.Assert(client.WaitForPubSub(5000), IsNil)
+	c.Assert(client.IntakePaused(), Equals, false)
+	c.Assert(client.Action(), Equals, "add")
+}
@@ -0,0 +1,219 @@
+//go:build integration
+// +build integration
+
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+// Package integration runs the client against a real pubsubsql server.
+//
+//	PUBSUBSQL_BINARY=/path/to/pubsubsql go test -tags integration ./integration
+//
+// PUBSUBSQL_URL may name a server binary to download instead of PUBSUBSQL_BINARY.
+// The server is launched with PUBSUBSQL_ARGS (default "start -port 7778") and reached at
+// PUBSUBSQL_ADDRESS (default localhost:7778). When PUBSUBSQL_BINARY is not set the tests
+// use an already running server at PUBSUBSQL_ADDRESS.
+package integration
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pubsubsql/client"
+	. "gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type IntegrationSuite struct {
+	address string
+	server  *exec.Cmd
+	client  *pubsubsql.Client
+	table   string
+}
+
+var _ = Suite(&IntegrationSuite{})
+
+func env(name string, def string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return def
+}
+
+func (s *IntegrationSuite) SetUpSuite(c *C) {
+	s.address = env("PUBSUBSQL_ADDRESS", "localhost:7778")
+	binary := os.Getenv("PUBSUBSQL_BINARY")
+	if url := os.Getenv("PUBSUBSQL_URL"); url != "" && binary == "" {
+		var err error
+		binary, err = download(url, c.MkDir())
+		c.Assert(err, IsNil)
+	}
+	if binary != "" {
+		s.server = exec.Command(binary, strings.Fields(env("PUBSUBSQL_ARGS", "start -port 7778"))...)
+		s.server.Stdout = os.Stdout
+		s.server.Stderr = os.Stderr
+		c.Assert(s.server.Start(), IsNil)
+	}
+	// wait for the server to accept connections
+	probe := new(pubsubsql.Client)
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		err := probe.Connect(s.address)
+		if err == nil {
+			probe.Disconnect()
+			return
+		}
+		if time.Now().After(deadline) {
+			s.TearDownSuite(c)
+			c.Skip("pubsubsql server not available at " + s.address + ": " + err.Error())
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// download fetches the server binary into dir and makes it executable
+func download(url string, dir string) (string, error) {
+	response, err := http.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("download %s: %s", url, response.Status)
+	}
+	file, err := ioutil.TempFile(dir, "pubsubsql")
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	if _, err = io.Copy(file, response.Body); err != nil {
+		return "", err
+	}
+	return file.Name(), file.Chmod(0755)
+}
+
+func (s *IntegrationSuite) TearDownSuite(c *C) {
+	if s.server != nil && s.server.Process != nil {
+		s.server.Process.Kill()
+		s.server.Wait()
+		s.server = nil
+	}
+}
+
+func (s *IntegrationSuite) SetUpTest(c *C) {
+	s.table = fmt.Sprintf("it%d", time.Now().UnixNano())
+	s.client = new(pubsubsql.Client)
+	c.Assert(s.client.Connect(s.address), IsNil)
+	c.Assert(s.client.Execute(fmt.Sprintf("key %s ticker", s.table)), IsNil)
+}
+
+func (s *IntegrationSuite) TearDownTest(c *C) {
+	s.client.Disconnect()
+}
+
+func (s *IntegrationSuite) exec(c *C, format string, args ...interface{}) {
+	command := fmt.Sprintf(format, args...)
+	c.Assert(s.client.Execute(command), IsNil, Commentf(command))
+}
+
+func (s *IntegrationSuite) rows(c *C) []map[string]string {
+	var rows []map[string]string
+	for {
+		more, err := s.client.NextRow()
+		c.Assert(err, IsNil)
+		if !more {
+			return rows
+		}
+		row := make(map[string]string)
+		for _, column := range s.client.Columns() {
+			row[column] = s.client.Value(column)
+		}
+		rows = append(rows, row)
+	}
+}
+
+func (s *IntegrationSuite) TestCommands(c *C) {
+	s.exec(c, "insert into %s (ticker, bid) values (IBM, 120)", s.table)
+	c.Assert(s.client.Action(), Equals, "insert")
+	s.exec(c, "insert into %s (ticker, bid) values (MSFT, 37)", s.table)
+
+	s.exec(c, "select * from %s", s.table)
+	c.Assert(s.client.Action(), Equals, "select")
+	c.Assert(s.rows(c), HasLen, 2)
+
+	s.exec(c, "update %s set bid = 121 where ticker = IBM", s.table)
+	c.Assert(s.client.Action(), Equals, "update")
+	c.Assert(s.client.RowCount(), Equals, 1)
+
+	s.exec(c, "select * from %s where ticker = IBM", s.table)
+	rows := s.rows(c)
+	c.Assert(rows, HasLen, 1)
+	c.Assert(rows[0]["bid"], Equals, "121")
+
+	s.exec(c, "delete from %s where ticker = MSFT", s.table)
+	c.Assert(s.client.Action(), Equals, "delete")
+	s.exec(c, "select * from %s", s.table)
+	c.Assert(s.rows(c), HasLen, 1)
+
+	c.Assert(s.client.Execute("this is not a command"), NotNil)
+}
+
+func (s *IntegrationSuite) TestLargeResultSet(c *C) {
+	for i := 0; i < 500; i++ {
+		c.Assert(s.client.Stream(fmt.Sprintf("insert into %s (ticker, bid) values (T%d, %d)", s.table, i, i)), IsNil)
+	}
+	s.exec(c, "select * from %s", s.table)
+	c.Assert(s.rows(c), HasLen, 500)
+}
+
+func (s *IntegrationSuite) TestSubscribeSnapshot(c *C) {
+	s.exec(c, "insert into %s (ticker, bid) values (IBM, 120)", s.table)
+	sub, err := s.client.Subscribe(s.table, nil)
+	c.Assert(err, IsNil)
+	c.Assert(s.client.WaitForPubSub(1000), IsNil)
+	c.Assert(s.client.Action(), Equals, "add")
+	c.Assert(s.client.PubSubId(), Equals, sub.PubSubId())
+	c.Assert(s.rows(c), HasLen, 1)
+}
+
+func (s *IntegrationSuite) TestSubscribeSkip(c *C) {
+	s.exec(c, "insert into %s (ticker, bid) values (IBM, 120)", s.table)
+	_, err := s.client.Subscribe(s.table, &pubsubsql.SubscribeOptions{Snapshot: pubsubsql.SkipSnapshot})
+	c.Assert(err, IsNil)
+	c.Assert(s.client.WaitForPubSub(200), NotNil)
+
+	producer := new(pubsubsql.Client)
+	c.Assert(producer.Connect(s.address), IsNil)
+	defer producer.Disconnect()
+	c.Assert(producer.Execute(fmt.Sprintf("update %s set bid = 122 where ticker = IBM", s.table)), IsNil)
+	c.Assert(s.client.WaitForPubSub(1000), IsNil)
+	c.Assert(s.client.Action(), Equals, "update")
+	c.Assert(s.client.PubSubTable(), Equals, s.table)
+}
+
+func (s *IntegrationSuite) TestPauseResume(c *C) {
+	sub, err := s.client.Subscribe(s.table, &pubsubsql.SubscribeOptions{Snapshot: pubsubsql.SkipSnapshot})
+	c.Assert(err, IsNil)
+	c.Assert(sub.Pause(), IsNil)
+	s.exec(c, "insert into %s (ticker, bid) values (IBM, 120)", s.table)
+	c.Assert(s.client.WaitForPubSub(200), NotNil)
+	c.Assert(sub.Resume(), IsNil)
+	s.exec(c, "insert into %s (ticker, bid) values (MSFT, 37)", s.table)
+	c.Assert(s.client.WaitForPubSub(1000), IsNil)
+	c.Assert(s.client.Action(), Equals, "insert")
+}
@@ -0,0 +1,41 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+//Querier is the request/response surface of a Client. Code depending on it instead of *Client
+//can be unit tested with the MockClient of the pubsubsqltest package.
+type Querier interface {
+	Execute(command string) error
+	ExecuteArgs(command string, args ...interface{}) error
+	Query(command string) (*ResultSet, error)
+	Action() string
+	RowCount() int
+	NextRow() (bool, error)
+	Value(column string) string
+	ValueByOrdinal(ordinal int) string
+	HasColumn(column string) bool
+	Columns() []string
+	ColumnCount() int
+}
+
+//PubSubClient adds connection management, streaming and published messages to Querier.
+type PubSubClient interface {
+	Querier
+	Connect(address string) error
+	Disconnect()
+	Connected() bool
+	Stream(command string) error
+	PubSubId() string
+	WaitForPubSub(timeout int) error
+}
+
+var _ PubSubClient = (*Client)(nil)
@@ -0,0 +1,98 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+//LegacyClient provides the original bool returning API with Ok, Failed and LastError
+//on top of Client, so existing code can migrate one call at a time.
+//The original Error method is named LastError since a method named Error would make
+//a LegacyClient satisfy the error interface.
+//The embedded Client exposes the error returning methods, for example
+//client.Client.Execute(command).
+//
+//Deprecated: use Client, whose methods return errors that work with errors.Is and errors.As.
+type LegacyClient struct {
+	Client
+	err error
+}
+
+//Connect connects the Client to the pubsubsql server and returns true on success.
+//
+//Deprecated: use Client.Connect.
+func (c *LegacyClient) Connect(address string) bool {
+	return c.set(c.Client.Connect(address))
+}
+
+//Execute executes a command against the pubsubsql server and returns true on success.
+//
+//Deprecated: use Client.Execute.
+func (c *LegacyClient) Execute(command string) bool {
+	return c.set(c.Client.Execute(command))
+}
+
+//Stream sends a command to the pubsubsql server and returns true on success.
+//
+//Deprecated: use Client.Stream.
+func (c *LegacyClient) Stream(command string) bool {
+	return c.set(c.Client.Stream(command))
+}
+
+//NextRow moves to the next row in the result set and returns false when all rows are read or on error.
+//
+//Deprecated: use Client.NextRow.
+func (c *LegacyClient) NextRow() bool {
+	ok, err := c.Client.NextRow()
+	c.set(err)
+	return ok
+}
+
+//WaitForPubSub waits for a published message and returns false on timeout or error.
+//
+//Deprecated: use Client.WaitForPubSub.
+func (c *LegacyClient) WaitForPubSub(timeout int) bool {
+	return c.set(c.Client.WaitForPubSub(timeout))
+}
+
+//Ok returns true if the last command succeeded.
+//
+//Deprecated: check the error returned by the Client methods.
+func (c *LegacyClient) Ok() bool {
+	return c.err == nil
+}
+
+//Failed returns true if the last command failed.
+//
+//Deprecated: check the error returned by the Client methods.
+func (c *LegacyClient) Failed() bool {
+	return c.err != nil
+}
+
+//LastError returns the error message of the last failed command or an empty string.
+//
+//Deprecated: check the error returned by the Client methods.
+func (c *LegacyClient) LastError() string {
+	if c.err == nil {
+		return ""
+	}
+	return c.err.Error()
+}
+
+//Err returns the error of the last failed command, easing the move to error values.
+//
+//Deprecated: check the error returned by the Client methods.
+func (c *LegacyClient) Err() error {
+	return c.err
+}
+
+func (c *LegacyClient) set(err error) bool {
+	c.err = err
+	return err == nil
+}
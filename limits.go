@@ -0,0 +1,126 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+//LimitPolicy selects what happens to a row exceeding the DecodeLimits.
+type LimitPolicy int
+
+const (
+	//TruncateValues cuts the values to fit the limits and reports a LimitError to Errors.
+	TruncateValues LimitPolicy = iota
+	//RejectMessage fails the call that read the response or published message with a LimitError.
+	RejectMessage
+)
+
+//DecodeLimits bounds the size of the decoded values, protecting consumers from a producer
+//inserting huge values into a busy table. Zero disables a limit.
+type DecodeLimits struct {
+	//MaxValueSize is the largest value in bytes.
+	MaxValueSize int
+	//MaxRowSize is the largest sum in bytes of the values of a row.
+	MaxRowSize int
+	Policy     LimitPolicy
+}
+
+//LimitError reports a row exceeding the DecodeLimits.
+type LimitError struct {
+	//Row is the zero based index of the row in its batch.
+	Row int
+	//Column is the column of the value exceeding MaxValueSize, empty when the row exceeded MaxRowSize.
+	Column string
+	Size   int
+	Limit  int
+}
+
+func (e *LimitError) Error() string {
+	if e.Column != "" {
+		return fmt.Sprintf("Value of column %s in row %d has %d bytes, the limit is %d", e.Column, e.Row, e.Size, e.Limit)
+	}
+	return fmt.Sprintf("Row %d has %d bytes, the limit is %d", e.Row, e.Size, e.Limit)
+}
+
+//SetDecodeLimits limits the size of the values of every decoded response and published message.
+func (c *Client) SetDecodeLimits(limits DecodeLimits) error {
+	if limits.MaxValueSize < 0 || limits.MaxRowSize < 0 {
+		return fmt.Errorf("Invalid decode limits: %d bytes per value, %d bytes per row", limits.MaxValueSize, limits.MaxRowSize)
+	}
+	if limits.Policy != TruncateValues && limits.Policy != RejectMessage {
+		return fmt.Errorf("Invalid limit policy: %d", limits.Policy)
+	}
+	c.limits = limits
+	return nil
+}
+
+// enforceLimits applies the decode limits to the rows of the current response
+func (c *Client) enforceLimits() error {
+	limits := c.limits
+	if limits.MaxValueSize == 0 && limits.MaxRowSize == 0 {
+		return nil
+	}
+	for r, row := range c.response.Data {
+		size := 0
+		for i, value := range row {
+			if limits.MaxValueSize > 0 && len(value) > limits.MaxValueSize {
+				err := &LimitError{Row: r, Column: c.columnName(i), Size: len(value), Limit: limits.MaxValueSize}
+				if limits.Policy == RejectMessage {
+					return err
+				}
+				c.report(err)
+				row[i] = truncate(value, limits.MaxValueSize)
+			}
+			size += len(row[i])
+		}
+		if limits.MaxRowSize == 0 || size <= limits.MaxRowSize {
+			continue
+		}
+		err := &LimitError{Row: r, Size: size, Limit: limits.MaxRowSize}
+		if limits.Policy == RejectMessage {
+			return err
+		}
+		c.report(err)
+		// cut the values from the end of the row
+		for i := len(row) - 1; i >= 0 && size > limits.MaxRowSize; i-- {
+			keep := len(row[i]) - (size - limits.MaxRowSize)
+			if keep < 0 {
+				keep = 0
+			}
+			size -= len(row[i])
+			row[i] = truncate(row[i], keep)
+			size += len(row[i])
+		}
+	}
+	return nil
+}
+
+// columnName returns the name of the column ordinal of the current response
+func (c *Client) columnName(ordinal int) string {
+	if ordinal < len(c.response.Columns) {
+		return c.response.Columns[ordinal]
+	}
+	return fmt.Sprintf("#%d", ordinal)
+}
+
+// truncate cuts the value to at most size bytes without splitting a character
+func truncate(value string, size int) string {
+	if len(value) <= size {
+		return value
+	}
+	for size > 0 && !utf8.RuneStart(value[size]) {
+		size--
+	}
+	return value[:size]
+}
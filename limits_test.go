@@ -0,0 +1,37 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+func (s *TestSuite) TestDecodeLimits(c *C) {
+	response := `{"status":"ok","action":"select","rows":2,"fromrow":1,"torow":2,"columns":["ticker","note"],"data":[["IBM","ok"],["MSFT","héllo world"]]}`
+	client := newPipeClient(response, response)
+	c.Assert(client.SetDecodeLimits(DecodeLimits{MaxValueSize: -1}), NotNil)
+	c.Assert(client.SetDecodeLimits(DecodeLimits{MaxValueSize: 2, Policy: LimitPolicy(3)}), NotNil)
+
+	c.Assert(client.SetDecodeLimits(DecodeLimits{MaxValueSize: 6, MaxRowSize: 6}), IsNil)
+	c.Assert(client.Execute("select * from stocks"), IsNil)
+	rows, err := client.AllRowsMaps()
+	c.Assert(err, IsNil)
+	// the value is cut to héllo, then the row is cut from its end before the two byte character
+	c.Assert(rows, DeepEquals, []map[string]string{{"ticker": "IBM", "note": "ok"}, {"ticker": "MSFT", "note": "h"}})
+	c.Assert(<-client.Errors(), ErrorMatches, "Value of column note in row 1 has 12 bytes, the limit is 6")
+	c.Assert(<-client.Errors(), ErrorMatches, "Row 1 has 10 bytes, the limit is 6")
+
+	c.Assert(client.SetDecodeLimits(DecodeLimits{MaxRowSize: 6, Policy: RejectMessage}), IsNil)
+	err = client.Execute("select * from stocks")
+	c.Assert(err, FitsTypeOf, &LimitError{})
+	c.Assert(err.(*LimitError).Size, Equals, 16)
+}
@@ -0,0 +1,276 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+// Command loadgen generates insert/update/select/subscribe workloads against a
+// pubsubsql server with concurrent clients and reports throughput and latency percentiles.
+//
+//	loadgen -address localhost:7777 -clients 8 -duration 30s -mix insert=20,update=60,select=20 -subscribers 2
+//
+// With -soak it instead cycles connect/subscribe/consume/disconnect for the duration
+// while tracking goroutine and heap growth, exiting with status 1 when a leak is suspected.
+//
+//	loadgen -soak -duration 4h -soak-interval 5m
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pubsubsql/client"
+)
+
+var (
+	address     = flag.String("address", "localhost:7777", "pubsubsql server address")
+	clients     = flag.Int("clients", 4, "number of concurrent command clients")
+	subscribers = flag.Int("subscribers", 0, "number of concurrent subscribing clients")
+	duration    = flag.Duration("duration", 10*time.Second, "duration of the test")
+	table       = flag.String("table", "loadgen", "table used by the workload, cleared at startup")
+	keys        = flag.Int("keys", 1000, "number of distinct keys")
+	mixFlag     = flag.String("mix", "insert=20,update=60,select=20", "weights of the command types")
+	stream      = flag.Bool("stream", false, "send insert and update with Stream instead of Execute")
+)
+
+// operation generates a command for the given key
+type operation struct {
+	name    string
+	weight  int
+	command func(key int) string
+}
+
+var operations = []*operation{
+	{name: "insert", command: func(int) string {
+		// keys of seeded rows are taken, insert a fresh one
+		return insertCommand(int(atomic.AddInt64(&nextKey, 1)))
+	}},
+	{name: "update", command: func(key int) string {
+		return fmt.Sprintf("update %s set bid = %d where ticker = T%d", *table, rand.Intn(1000), key)
+	}},
+	{name: "select", command: func(key int) string {
+		return fmt.Sprintf("select * from %s where ticker = T%d", *table, key)
+	}},
+}
+
+// nextKey is the last key used by an insert, keys below -keys are seeded by setup
+var nextKey int64
+
+func insertCommand(key int) string {
+	return fmt.Sprintf("insert into %s (ticker, bid) values (T%d, %d)", *table, key, rand.Intn(1000))
+}
+
+// result collects latencies of one worker
+type result struct {
+	latencies map[string][]time.Duration
+	errors    int
+}
+
+func main() {
+	flag.Parse()
+	if err := parseMix(*mixFlag); err != nil {
+		fmt.Fprintln(os.Stderr, "invalid mix:", err)
+		os.Exit(2)
+	}
+	if err := setup(); err != nil {
+		fmt.Fprintln(os.Stderr, "setup failed:", err)
+		os.Exit(1)
+	}
+	if *soak {
+		if !runSoak() {
+			os.Exit(1)
+		}
+		return
+	}
+	deadline := time.Now().Add(*duration)
+	var published int64
+	var wg sync.WaitGroup
+	for i := 0; i < *subscribers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			subscribe(deadline, &published)
+		}()
+	}
+	results := make([]*result, *clients)
+	for i := range results {
+		results[i] = &result{latencies: make(map[string][]time.Duration)}
+		wg.Add(1)
+		go func(r *result) {
+			defer wg.Done()
+			work(deadline, r)
+		}(results[i])
+	}
+	start := time.Now()
+	wg.Wait()
+	report(results, time.Since(start), atomic.LoadInt64(&published))
+}
+
+func parseMix(mix string) error {
+	for _, part := range strings.Split(mix, ",") {
+		pair := strings.SplitN(part, "=", 2)
+		if len(pair) != 2 {
+			return fmt.Errorf("expected name=weight got %q", part)
+		}
+		weight, err := strconv.Atoi(pair[1])
+		if err != nil || weight < 0 {
+			return fmt.Errorf("invalid weight %q", pair[1])
+		}
+		found := false
+		for _, op := range operations {
+			if op.name == strings.TrimSpace(pair[0]) {
+				op.weight = weight
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("unknown command type %q", pair[0])
+		}
+	}
+	for _, op := range operations {
+		if op.weight > 0 {
+			return nil
+		}
+	}
+	return fmt.Errorf("all weights are zero")
+}
+
+func setup() error {
+	c := new(pubsubsql.Client)
+	if err := c.Connect(*address); err != nil {
+		return err
+	}
+	defer c.Disconnect()
+	if err := c.Execute(fmt.Sprintf("key %s ticker", *table)); err != nil {
+		fmt.Fprintln(os.Stderr, "key failed, it may exist from a previous run:", err)
+	}
+	// rows left by a previous run would make the seed inserts fail
+	if err := c.Execute(fmt.Sprintf("delete from %s", *table)); err != nil {
+		return err
+	}
+	for key := 0; key < *keys; key++ {
+		if err := c.Execute(insertCommand(key)); err != nil {
+			return err
+		}
+	}
+	nextKey = int64(*keys) - 1
+	return nil
+}
+
+func pick() *operation {
+	total := 0
+	for _, op := range operations {
+		total += op.weight
+	}
+	n := rand.Intn(total)
+	for _, op := range operations {
+		if n < op.weight {
+			return op
+		}
+		n -= op.weight
+	}
+	return operations[len(operations)-1]
+}
+
+func work(deadline time.Time, r *result) {
+	c := new(pubsubsql.Client)
+	if err := c.Connect(*address); err != nil {
+		fmt.Fprintln(os.Stderr, "connect failed:", err)
+		r.errors++
+		return
+	}
+	defer c.Disconnect()
+	for time.Now().Before(deadline) {
+		op := pick()
+		command := op.command(rand.Intn(*keys))
+		start := time.Now()
+		var err error
+		if *stream && op.name != "select" {
+			err = c.Stream(command)
+		} else {
+			err = c.Execute(command)
+			for err == nil {
+				var more bool
+				more, err = c.NextRow()
+				if !more {
+					break
+				}
+			}
+		}
+		if err != nil {
+			r.errors++
+			continue
+		}
+		r.latencies[op.name] = append(r.latencies[op.name], time.Since(start))
+	}
+}
+
+func subscribe(deadline time.Time, published *int64) {
+	c := new(pubsubsql.Client)
+	if err := c.Connect(*address); err != nil {
+		fmt.Fprintln(os.Stderr, "connect failed:", err)
+		return
+	}
+	defer c.Disconnect()
+	if err := c.Execute(fmt.Sprintf("subscribe skip * from %s", *table)); err != nil {
+		fmt.Fprintln(os.Stderr, "subscribe failed:", err)
+		return
+	}
+	for time.Now().Before(deadline) {
+		if err := c.WaitForPubSub(100); err != nil {
+			continue
+		}
+		atomic.AddInt64(published, int64(c.RowCount()))
+	}
+}
+
+func report(results []*result, elapsed time.Duration, published int64) {
+	merged := make(map[string][]time.Duration)
+	errors := 0
+	for _, r := range results {
+		for name, latencies := range r.latencies {
+			merged[name] = append(merged[name], latencies...)
+		}
+		errors += r.errors
+	}
+	fmt.Printf("%-8s %10s %10s %10s %10s %10s %10s\n", "command", "count", "ops/sec", "p50", "p90", "p99", "max")
+	total := 0
+	for _, op := range operations {
+		latencies := merged[op.name]
+		if len(latencies) == 0 {
+			continue
+		}
+		total += len(latencies)
+		sort.Sort(durations(latencies))
+		fmt.Printf("%-8s %10d %10.0f %10s %10s %10s %10s\n", op.name, len(latencies),
+			float64(len(latencies))/elapsed.Seconds(), percentile(latencies, 50), percentile(latencies, 90),
+			percentile(latencies, 99), latencies[len(latencies)-1])
+	}
+	fmt.Printf("total %d commands in %s (%.0f ops/sec), %d errors\n", total, elapsed, float64(total)/elapsed.Seconds(), errors)
+	if *subscribers > 0 {
+		fmt.Printf("subscribers received %d rows (%.0f rows/sec)\n", published, float64(published)/elapsed.Seconds())
+	}
+}
+
+func percentile(sorted []time.Duration, p int) time.Duration {
+	return sorted[(len(sorted)-1)*p/100]
+}
+
+type durations []time.Duration
+
+func (d durations) Len() int           { return len(d) }
+func (d durations) Less(i, j int) bool { return d[i] < d[j] }
+func (d durations) Swap(i, j int)      { d[i], d[j] = d[j], d[i] }
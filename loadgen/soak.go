@@ -0,0 +1,107 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/pubsubsql/client"
+)
+
+var (
+	soak               = flag.Bool("soak", false, "run the soak test instead of the load test")
+	soakInterval       = flag.Duration("soak-interval", time.Minute, "interval between soak resource samples")
+	maxGoroutineGrowth = flag.Int("max-goroutine-growth", 10, "goroutine growth tolerated by the soak test")
+	maxHeapGrowth      = flag.Int("max-heap-growth", 64, "heap growth in MB tolerated by the soak test")
+)
+
+// sample is a snapshot of the process resources
+type sample struct {
+	goroutines int
+	heap       uint64
+}
+
+func takeSample() sample {
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return sample{goroutines: runtime.NumGoroutine(), heap: stats.HeapAlloc}
+}
+
+// runSoak cycles connect/subscribe/consume/disconnect until the duration elapses
+// while a producer keeps updating the table, and reports resource growth.
+// It returns false when growth exceeds the configured limits.
+func runSoak() bool {
+	producer := new(pubsubsql.Client)
+	if err := producer.Connect(*address); err != nil {
+		fmt.Fprintln(os.Stderr, "connect failed:", err)
+		return false
+	}
+	defer producer.Disconnect()
+	deadline := time.Now().Add(*duration)
+	baseline := takeSample()
+	nextSample := time.Now().Add(*soakInterval)
+	cycles, received, errors := 0, 0, 0
+	for time.Now().Before(deadline) {
+		n, err := soakCycle(producer)
+		cycles++
+		received += n
+		if err != nil {
+			errors++
+		}
+		if time.Now().After(nextSample) {
+			current := takeSample()
+			fmt.Printf("cycles %d, received %d, errors %d, goroutines %d (%+d), heap %d KB (%+d KB)\n",
+				cycles, received, errors, current.goroutines, current.goroutines-baseline.goroutines,
+				current.heap/1024, (int64(current.heap)-int64(baseline.heap))/1024)
+			nextSample = time.Now().Add(*soakInterval)
+		}
+	}
+	final := takeSample()
+	goroutineGrowth := final.goroutines - baseline.goroutines
+	heapGrowth := (int64(final.heap) - int64(baseline.heap)) / (1024 * 1024)
+	fmt.Printf("soak finished: cycles %d, received %d, errors %d, goroutine growth %d, heap growth %d MB\n",
+		cycles, received, errors, goroutineGrowth, heapGrowth)
+	if goroutineGrowth > *maxGoroutineGrowth || heapGrowth > int64(*maxHeapGrowth) {
+		fmt.Println("LEAK SUSPECTED")
+		return false
+	}
+	return true
+}
+
+// soakCycle connects a new client, subscribes, consumes published rows and disconnects
+func soakCycle(producer *pubsubsql.Client) (int, error) {
+	c := new(pubsubsql.Client)
+	if err := c.Connect(*address); err != nil {
+		return 0, err
+	}
+	defer c.Disconnect()
+	if err := c.Execute(fmt.Sprintf("subscribe * from %s", *table)); err != nil {
+		return 0, err
+	}
+	received := 0
+	for i := 0; i < 10; i++ {
+		if err := producer.Stream(operations[1].command(rand.Intn(*keys))); err != nil {
+			return received, err
+		}
+		if err := c.WaitForPubSub(100); err != nil {
+			continue
+		}
+		received += c.RowCount()
+	}
+	return received, nil
+}
@@ -0,0 +1,109 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+//DefaultMigrationsTable is the table recording applied migrations when Migrate is given no table.
+const DefaultMigrationsTable = "pubsubsql_migrations"
+
+//Migration is a numbered list of setup commands, such as key, tag and insert commands.
+type Migration struct {
+	Version     int
+	Description string
+	Commands    []string
+}
+
+//Migrate executes the commands of every migration that is not recorded in the versions table yet,
+//in the order of their versions, and records each migration once all its commands succeeded.
+//Versions must be positive and increasing. Migrate returns the versions it applied.
+//The commands of a migration are not executed atomically: when one fails Migrate stops,
+//the migration stays unrecorded and the commands that already succeeded are not undone,
+//so migrations should be safe to execute again.
+func Migrate(client *Client, table string, migrations []Migration) ([]int, error) {
+	if table == "" {
+		table = DefaultMigrationsTable
+	}
+	if !isIdentifier(table) {
+		return nil, fmt.Errorf("Invalid table name: %q", table)
+	}
+	for i, migration := range migrations {
+		if migration.Version <= 0 || (i > 0 && migration.Version <= migrations[i-1].Version) {
+			return nil, fmt.Errorf("Migration versions must be positive and increasing: %d", migration.Version)
+		}
+	}
+	done, err := appliedMigrations(client, table)
+	if err != nil {
+		return nil, err
+	}
+	var applied []int
+	for _, migration := range migrations {
+		if done[migration.Version] {
+			continue
+		}
+		for i, command := range migration.Commands {
+			err = client.Execute(command)
+			if err != nil {
+				return applied, fmt.Errorf("Migration %d command %d: %w", migration.Version, i, err)
+			}
+		}
+		record, _ := InsertCommands(table, []string{"version", "description"}, [][]string{{strconv.Itoa(migration.Version), migration.Description}})
+		err = client.Execute(record[0])
+		if err != nil {
+			return applied, fmt.Errorf("Migration %d: %w", migration.Version, err)
+		}
+		applied = append(applied, migration.Version)
+	}
+	return applied, nil
+}
+
+// appliedMigrations returns the versions recorded in the table,
+// a table the pubsubsql server does not know yet holds no versions
+func appliedMigrations(client *Client, table string) (map[int]bool, error) {
+	done := make(map[int]bool)
+	err := client.Execute("select * from " + table)
+	if err != nil {
+		if unknownTable(err) {
+			return done, nil
+		}
+		return nil, err
+	}
+	for {
+		more, err := client.NextRow()
+		if err != nil {
+			return nil, err
+		}
+		if !more {
+			return done, nil
+		}
+		version, err := strconv.Atoi(client.Value("version"))
+		if err != nil {
+			return nil, fmt.Errorf("Invalid migration version %q in %s", client.Value("version"), table)
+		}
+		done[version] = true
+	}
+}
+
+// unknownTable returns true if the pubsubsql server rejected the command because the table does not exist
+func unknownTable(err error) bool {
+	var responseError *ResponseError
+	if !errors.As(err, &responseError) {
+		return false
+	}
+	message := strings.ToLower(responseError.Message)
+	return strings.Contains(message, "table") && strings.Contains(message, "does not exist")
+}
@@ -0,0 +1,72 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"errors"
+	. "gopkg.in/check.v1"
+	"strings"
+)
+
+func (s *TestSuite) TestMigrate(c *C) {
+	client := newPipeClient(
+		`{"status":"ok","action":"select","rows":1,"fromrow":1,"torow":1,"columns":["id","version","description"],"data":[["1","1","stocks key"]]}`,
+		`{"status":"ok","action":"tag"}`,
+		`{"status":"ok","action":"insert","id":"2"}`,
+		`{"status":"ok","action":"insert","id":"1"}`,
+		`{"status":"err","msg":"invalid command"}`,
+	)
+	observer := new(recordingObserver)
+	client.SetObserver(observer)
+	migrations := []Migration{
+		{Version: 1, Description: "stocks key", Commands: []string{"key stocks ticker"}},
+		{Version: 2, Description: "sector tag", Commands: []string{"tag stocks sector"}},
+		{Version: 3, Description: "seed", Commands: []string{"insert into stocks (ticker) values (IBM)", "bogus"}},
+	}
+	applied, err := Migrate(client, "", migrations)
+	c.Assert(err, ErrorMatches, "Migration 3 command 1: .*invalid command")
+	c.Assert(applied, DeepEquals, []int{2})
+	c.Assert(observer.writes(), DeepEquals, []string{
+		"select * from pubsubsql_migrations",
+		"tag stocks sector",
+		"insert into pubsubsql_migrations (version,description) values (2,'sector tag')",
+		"insert into stocks (ticker) values (IBM)",
+		"bogus",
+	})
+}
+
+func (s *TestSuite) TestMigrateNewTable(c *C) {
+	client := newPipeClient(
+		`{"status":"err","msg":"table does not exist"}`,
+		`{"status":"ok","action":"key"}`,
+		`{"status":"ok","action":"insert","id":"1"}`,
+	)
+	applied, err := Migrate(client, "versions", []Migration{{Version: 1, Commands: []string{"key stocks ticker"}}})
+	c.Assert(err, IsNil)
+	c.Assert(applied, DeepEquals, []int{1})
+
+	_, err = Migrate(client, "versions", []Migration{{Version: 2}, {Version: 1}})
+	c.Assert(err, NotNil)
+
+	// other errors of the pubsubsql server do not mean that no migration was applied
+	client = newPipeClient(`{"status":"err","msg":"server is busy"}`)
+	applied, err = Migrate(client, "versions", []Migration{{Version: 1, Commands: []string{"key stocks ticker"}}})
+	c.Assert(err, ErrorMatches, "response error: server is busy")
+	c.Assert(applied, IsNil)
+}
+
+func (s *TestSuite) TestMigrateWrapsErrors(c *C) {
+	client := newPipeClient(`{"status":"ok","action":"select","rows":0,"fromrow":0,"torow":0,"columns":[],"data":[]}`)
+	huge := "insert into stocks (ticker) values (" + strings.Repeat("x", _MAX_COMMAND_SIZE) + ")"
+	_, err := Migrate(client, "versions", []Migration{{Version: 1, Commands: []string{huge}}})
+	c.Assert(errors.Is(err, ErrCommandTooLarge), Equals, true)
+}
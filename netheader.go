@@ -23,33 +23,43 @@ import (
 --------------------+--------------------
 */
 
-type netHeader struct {
+//Header frames every message exchanged with the pubsubsql server.
+//It is exported for transports, proxies and debugging tools that handle the framing themselves.
+type Header struct {
+	//MessageSize is the size in bytes of the message following the header.
 	MessageSize uint32
-	RequestId   uint32
+	//RequestId identifies the command the message answers, 0 for published messages.
+	RequestId uint32
 }
 
-var _HEADER_SIZE = 8
-var _EMPTY_HEADER = make([]byte, _HEADER_SIZE, _HEADER_SIZE)
+//HeaderSize is the size in bytes of an encoded Header.
+const HeaderSize = 8
 
-func newNetHeader(messageSize uint32, requestId uint32) *netHeader {
-	return &netHeader{
+var _EMPTY_HEADER = make([]byte, HeaderSize, HeaderSize)
+
+//NewHeader returns the header of a message.
+func NewHeader(messageSize uint32, requestId uint32) *Header {
+	return &Header{
 		MessageSize: messageSize,
 		RequestId:   requestId,
 	}
 }
 
-func (this *netHeader) readFrom(bytes []byte) {
+//Decode reads the header from the first HeaderSize bytes.
+func (this *Header) Decode(bytes []byte) {
 	this.MessageSize = binary.BigEndian.Uint32(bytes)
 	this.RequestId = binary.BigEndian.Uint32(bytes[4:])
 }
 
-func (this *netHeader) writeTo(bytes []byte) {
+//Encode writes the header into the first HeaderSize bytes.
+func (this *Header) Encode(bytes []byte) {
 	binary.BigEndian.PutUint32(bytes, this.MessageSize)
 	binary.BigEndian.PutUint32(bytes[4:], this.RequestId)
 }
 
-func (this *netHeader) getBytes() []byte {
-	bytes := make([]byte, _HEADER_SIZE, _HEADER_SIZE)
-	this.writeTo(bytes)
+//Bytes returns the encoded header.
+func (this *Header) Bytes() []byte {
+	bytes := make([]byte, HeaderSize, HeaderSize)
+	this.Encode(bytes)
 	return bytes
 }
@@ -16,14 +16,14 @@ import (
 	. "gopkg.in/check.v1"
 )
 
-func (s *TestSuite) TestreadFrom(c *C) {
-	nh := netHeader{}
+func (s *TestSuite) TestHeaderDecode(c *C) {
+	nh := Header{}
 
 	source_bytes := []byte("123456789")
-	nh.readFrom(source_bytes)
+	nh.Decode(source_bytes)
 
 	destination := make([]byte, 15, 15)
-	nh.writeTo(destination)
+	nh.Encode(destination)
 	// First 8 bytes of the header need to match
 	c.Assert(source_bytes[:8], DeepEquals, destination[:8])
 
@@ -12,6 +12,7 @@
 package pubsubsql
 
 import (
+	"crypto/tls"
 	"errors"
 	"net"
 	"time"
@@ -19,8 +20,14 @@ import (
 
 // message reader
 type netHelper struct {
-	conn  net.Conn
-	bytes []byte
+	conn       net.Conn
+	bytes      []byte
+	bufferSize int
+	// largest buffer allocated
+	highWater int
+	// shrink the buffer when no large message was read for this long
+	shrinkAfter time.Duration
+	lastLarge   time.Time
 }
 
 func newnetHelper(conn net.Conn, bufferSize int) *netHelper {
@@ -32,6 +39,10 @@ func newnetHelper(conn net.Conn, bufferSize int) *netHelper {
 func (this *netHelper) set(conn net.Conn, bufferSize int) {
 	this.conn = conn
 	this.bytes = make([]byte, bufferSize, bufferSize)
+	this.bufferSize = bufferSize
+	if bufferSize > this.highWater {
+		this.highWater = bufferSize
+	}
 }
 
 func (this *netHelper) close() {
@@ -45,6 +56,17 @@ func (this *netHelper) valid() bool {
 	return this.conn != nil
 }
 
+// setNoDelay enables or disables Nagle's algorithm on TCP connections
+func (this *netHelper) setNoDelay(noDelay bool) {
+	conn := this.conn
+	if t, ok := conn.(*tls.Conn); ok {
+		conn = t.NetConn()
+	}
+	if tcp, ok := conn.(*net.TCPConn); ok {
+		tcp.SetNoDelay(noDelay)
+	}
+}
+
 func (this *netHelper) writeMessage(bytes []byte) error {
 	leftToWrite := len(bytes)
 	for {
@@ -62,16 +84,33 @@ func (this *netHelper) writeMessage(bytes []byte) error {
 }
 
 func (this *netHelper) writeHeaderAndMessage(requestId uint32, bytes []byte) error {
-	err := this.writeMessage(newNetHeader(uint32(len(bytes)), requestId).getBytes())
+	err := this.writeMessage(NewHeader(uint32(len(bytes)), requestId).Bytes())
 	if err != nil {
 		return err
 	}
 	return this.writeMessage(bytes)
 }
 
-func (this *netHelper) readMessageTimeout(milliseconds int64) (*netHeader, []byte, error, bool) {
-	this.conn.SetReadDeadline(time.Now().Add(time.Duration(milliseconds) * time.Millisecond))
-	header, bytes, err := this.readMessage()
+// writeHeaderAndMessageTimeout writes the message failing with ErrWriteTimeout when it takes longer than timeout,
+// zero timeout means no timeout
+func (this *netHelper) writeHeaderAndMessageTimeout(requestId uint32, bytes []byte, timeout time.Duration) error {
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+	this.conn.SetWriteDeadline(deadline)
+	err := this.writeHeaderAndMessage(requestId, bytes)
+	if neterr, ok := err.(net.Error); ok && neterr.Timeout() {
+		err = ErrWriteTimeout
+	}
+	return err
+}
+
+// readMessageTimeout reads from conn, the connection taken under the lock of the writers
+// since a failed write may close the helper while the message is read
+func (this *netHelper) readMessageTimeout(conn net.Conn, milliseconds int64) (*Header, []byte, error, bool) {
+	conn.SetReadDeadline(time.Now().Add(time.Duration(milliseconds) * time.Millisecond))
+	header, bytes, err := this.readFrom(conn)
 	timedout := false
 	if neterr, ok := err.(net.Error); ok && neterr.Timeout() {
 		timedout = true
@@ -80,21 +119,34 @@ func (this *netHelper) readMessageTimeout(milliseconds int64) (*netHeader, []byt
 	return header, bytes, err, timedout
 }
 
-func (this *netHelper) readMessage() (*netHeader, []byte, error) {
+func (this *netHelper) readMessage() (*Header, []byte, error) {
+	return this.readFrom(this.conn)
+}
+
+func (this *netHelper) readFrom(conn net.Conn) (*Header, []byte, error) {
 	// header
-	read, err := this.conn.Read(this.bytes[0:_HEADER_SIZE])
+	read, err := conn.Read(this.bytes[0:HeaderSize])
 	if err != nil {
 		return nil, nil, err
 	}
-	if read < _HEADER_SIZE {
+	if read < HeaderSize {
 		err = errors.New("Failed to read header.")
 		return nil, nil, err
 	}
-	var header netHeader
-	header.readFrom(this.bytes)
+	var header Header
+	header.Decode(this.bytes)
 	// prepare buffer
-	if len(this.bytes) < int(header.MessageSize) {
-		this.bytes = make([]byte, header.MessageSize, header.MessageSize)
+	size := int(header.MessageSize)
+	if size > this.bufferSize {
+		this.lastLarge = _NOW()
+	} else if this.shrinkAfter > 0 && len(this.bytes) > this.bufferSize && since(this.lastLarge) > this.shrinkAfter {
+		this.bytes = make([]byte, this.bufferSize, this.bufferSize)
+	}
+	if len(this.bytes) < size {
+		this.bytes = make([]byte, size, size)
+		if size > this.highWater {
+			this.highWater = size
+		}
 	}
 	// message
 	bytes := this.bytes[:header.MessageSize]
@@ -103,7 +155,7 @@ func (this *netHelper) readMessage() (*netHeader, []byte, error) {
 	read = 0
 	for left > 0 {
 		bytes = bytes[read:]
-		read, err = this.conn.Read(bytes)
+		read, err = conn.Read(bytes)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -0,0 +1,41 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	. "gopkg.in/check.v1"
+	"net"
+	"strings"
+	"time"
+)
+
+func (s *TestSuite) TestBufferShrink(c *C) {
+	conn, server := net.Pipe()
+	rw := newnetHelper(conn, 16)
+	rw.shrinkAfter = time.Millisecond
+	go func() {
+		writer := newnetHelper(server, 16)
+		writer.writeHeaderAndMessage(0, []byte(strings.Repeat("x", 100)))
+		time.Sleep(5 * time.Millisecond)
+		writer.writeHeaderAndMessage(0, []byte("small"))
+	}()
+	_, message, err := rw.readMessage()
+	c.Assert(err, IsNil)
+	c.Assert(message, HasLen, 100)
+	c.Assert(rw.highWater, Equals, 100)
+
+	_, message, err = rw.readMessage()
+	c.Assert(err, IsNil)
+	c.Assert(string(message), Equals, "small")
+	c.Assert(rw.bytes, HasLen, 16)
+	c.Assert(rw.highWater, Equals, 100)
+}
@@ -0,0 +1,114 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log"
+	"time"
+)
+
+// time allowed to establish a connection unless changed with WithConnectTimeout
+const _DEFAULT_CONNECT_TIMEOUT = time.Second
+
+//Option configures a Client created by NewClient.
+type Option func(c *Client) error
+
+//NewClient returns a Client configured with the options, the zero value Client uses the defaults.
+//The Client is not connected, call Connect.
+func NewClient(opts ...Option) (*Client, error) {
+	c := new(Client)
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+//WithBufferSize sets the initial size in bytes of the read buffer of every connection, 2048 by default.
+//Larger messages grow the buffer, see SetBufferShrinkAfter.
+func WithBufferSize(size int) Option {
+	return func(c *Client) error {
+		if size < HeaderSize {
+			return fmt.Errorf("Invalid buffer size: %d", size)
+		}
+		c.bufferSize = size
+		return nil
+	}
+}
+
+//WithConnectTimeout sets the maximum time to establish a connection, 1 second by default.
+func WithConnectTimeout(timeout time.Duration) Option {
+	return func(c *Client) error {
+		if timeout <= 0 {
+			return fmt.Errorf("Invalid connect timeout: %v", timeout)
+		}
+		c.SetConnectTimeout(timeout)
+		return nil
+	}
+}
+
+//WithReadTimeout sets the read timeout, 3 minutes by default, see SetReadTimeout.
+func WithReadTimeout(timeout time.Duration) Option {
+	return func(c *Client) error {
+		if timeout <= 0 {
+			return fmt.Errorf("Invalid read timeout: %v", timeout)
+		}
+		c.SetReadTimeout(timeout)
+		return nil
+	}
+}
+
+//WithWriteTimeout sets the write timeout, see SetWriteTimeout.
+func WithWriteTimeout(timeout time.Duration) Option {
+	return func(c *Client) error {
+		if timeout < 0 {
+			return fmt.Errorf("Invalid write timeout: %v", timeout)
+		}
+		c.SetWriteTimeout(timeout)
+		return nil
+	}
+}
+
+//WithLogger makes the Client write its trace messages to logger instead of the standard logger.
+func WithLogger(logger *log.Logger) Option {
+	return func(c *Client) error {
+		c.logger = logger
+		return nil
+	}
+}
+
+//WithDialer opens the connections with dialer, see SetDialer.
+func WithDialer(dialer Dialer) Option {
+	return func(c *Client) error {
+		c.SetDialer(dialer)
+		return nil
+	}
+}
+
+//WithTLS encrypts the connections, see SetTLSConfig.
+func WithTLS(cfg *tls.Config) Option {
+	return func(c *Client) error {
+		c.SetTLSConfig(cfg)
+		return nil
+	}
+}
+
+// readBufferSize returns the configured or default size of the read buffers
+func (c *Client) readBufferSize() int {
+	if c.bufferSize > 0 {
+		return c.bufferSize
+	}
+	return _CLIENT_DEFAULT_BUFFER_SIZE
+}
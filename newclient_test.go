@@ -0,0 +1,46 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"bytes"
+	. "gopkg.in/check.v1"
+	"log"
+	"time"
+)
+
+func (s *TestSuite) TestNewClient(c *C) {
+	var trace bytes.Buffer
+	client, err := NewClient(
+		WithBufferSize(64),
+		WithConnectTimeout(50*time.Millisecond),
+		WithWriteTimeout(time.Second),
+		WithLogger(log.New(&trace, "", 0)),
+	)
+	c.Assert(err, IsNil)
+	c.Assert(client.writeTimeout, Equals, time.Second)
+
+	address := serveConnections(c, []string{`{"status":"ok","action":"subscribe","pubsubid":"1"}`})
+	c.Assert(client.Connect(address), IsNil)
+	c.Assert(client.rw.bufferSize, Equals, 64)
+	c.Assert(client.Execute("subscribe * from stocks"), IsNil)
+	client.WaitForPubSub(10)
+	c.Assert(trace.String(), Matches, "(?s)Waiting for PUB SUB.*")
+	client.Disconnect()
+
+	_, err = NewClient(WithBufferSize(4))
+	c.Assert(err, ErrorMatches, "Invalid buffer size: 4")
+	_, err = NewClient(WithReadTimeout(-time.Second))
+	c.Assert(err, ErrorMatches, "Invalid read timeout.*")
+	_, err = NewClient(WithConnectTimeout(0))
+	c.Assert(err, ErrorMatches, "Invalid connect timeout.*")
+}
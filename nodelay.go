@@ -0,0 +1,83 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"time"
+)
+
+//WriteMode selects how the Client trades latency against throughput when writing to the connection.
+type WriteMode int
+
+const (
+	//LatencyMode sends every command immediately by disabling Nagle's algorithm (TCP_NODELAY), the default.
+	LatencyMode WriteMode = iota
+	//ThroughputMode lets the operating system coalesce small writes into fewer packets.
+	ThroughputMode
+	//AdaptiveMode switches to throughput mode during bursts of writes and back to latency mode once idle.
+	AdaptiveMode
+)
+
+var (
+	// writes closer than this belong to a burst
+	_BURST_GAP = time.Millisecond
+	// writes in a burst before switching to throughput mode
+	_BURST_WRITES = 8
+	// idle time before switching back to latency mode
+	_IDLE_GAP = 10 * time.Millisecond
+)
+
+// writePacing tracks the write pattern in adaptive mode
+type writePacing struct {
+	mode       WriteMode
+	throughput bool
+	burst      int
+	lastWrite  time.Time
+}
+
+// observe records a write and returns true when the connection must switch mode
+func (this *writePacing) observe(now time.Time) bool {
+	if this.mode != AdaptiveMode {
+		return false
+	}
+	gap := now.Sub(this.lastWrite)
+	this.lastWrite = now
+	if gap < _BURST_GAP {
+		this.burst++
+	} else {
+		this.burst = 0
+	}
+	switch {
+	case !this.throughput && this.burst >= _BURST_WRITES:
+		this.throughput = true
+		return true
+	case this.throughput && gap > _IDLE_GAP:
+		this.throughput = false
+		return true
+	}
+	return false
+}
+
+//SetWriteMode selects how commands are written to the connection.
+//The mode only affects TCP connections.
+func (c *Client) SetWriteMode(mode WriteMode) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	c.pacing = writePacing{mode: mode, throughput: mode == ThroughputMode}
+	c.applyWriteModeLocked()
+}
+
+// applyWriteModeLocked sets TCP_NODELAY on the connections according to the write mode
+func (c *Client) applyWriteModeLocked() {
+	c.rw.setNoDelay(!c.pacing.throughput)
+	c.subrw.setNoDelay(!c.pacing.throughput)
+}
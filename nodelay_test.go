@@ -0,0 +1,48 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	. "gopkg.in/check.v1"
+	"time"
+)
+
+func (s *TestSuite) TestWritePacing(c *C) {
+	pacing := writePacing{mode: AdaptiveMode}
+	now := time.Now()
+	switched := 0
+	for i := 0; i <= _BURST_WRITES; i++ {
+		if pacing.observe(now) {
+			switched++
+		}
+		now = now.Add(_BURST_GAP / 10)
+	}
+	c.Assert(switched, Equals, 1)
+	c.Assert(pacing.throughput, Equals, true)
+	// a write after an idle period restores latency mode
+	c.Assert(pacing.observe(now.Add(_IDLE_GAP*2)), Equals, true)
+	c.Assert(pacing.throughput, Equals, false)
+
+	static := writePacing{mode: ThroughputMode, throughput: true}
+	c.Assert(static.observe(now), Equals, false)
+	c.Assert(static.observe(now.Add(time.Hour)), Equals, false)
+	c.Assert(static.throughput, Equals, true)
+}
+
+func (s *TestSuite) TestSetWriteMode(c *C) {
+	client := newPipeClient(`{"status":"ok","action":"status"}`)
+	client.SetWriteMode(AdaptiveMode)
+	c.Assert(client.Execute("status"), IsNil)
+	c.Assert(client.pacing.mode, Equals, AdaptiveMode)
+	client.SetWriteMode(ThroughputMode)
+	c.Assert(client.pacing.throughput, Equals, true)
+}
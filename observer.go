@@ -0,0 +1,32 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+//Observer receives low level protocol events of a Client, for custom metrics, wire logging or protocol analyzers.
+//The message slices are reused by the Client and must not be retained after the call returns.
+//OnFrameWrite may be called from the goroutines calling Stream concurrently.
+type Observer interface {
+	//OnFrameRead is called for every frame read from the connection; published messages have request id 0.
+	OnFrameRead(requestId uint32, message []byte)
+	//OnFrameWrite is called for every frame written to the connection.
+	OnFrameWrite(requestId uint32, message []byte)
+	//OnDecodeError is called when a message can not be decoded.
+	OnDecodeError(message []byte, err error)
+}
+
+//SetObserver installs the observer receiving protocol events of the Client.
+//Pass nil to remove the observer.
+func (c *Client) SetObserver(observer Observer) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	c.observer = observer
+}
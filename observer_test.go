@@ -0,0 +1,59 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	. "gopkg.in/check.v1"
+	"strings"
+)
+
+type recordingObserver struct {
+	events []string
+}
+
+func (o *recordingObserver) OnFrameRead(requestId uint32, message []byte) {
+	o.events = append(o.events, "read "+string(message))
+}
+
+func (o *recordingObserver) OnFrameWrite(requestId uint32, message []byte) {
+	o.events = append(o.events, "write "+string(message))
+}
+
+func (o *recordingObserver) OnDecodeError(message []byte, err error) {
+	o.events = append(o.events, "error "+string(message))
+}
+
+// writes returns the written messages
+func (o *recordingObserver) writes() []string {
+	var messages []string
+	for _, event := range o.events {
+		if strings.HasPrefix(event, "write ") {
+			messages = append(messages, event[len("write "):])
+		}
+	}
+	return messages
+}
+
+func (s *TestSuite) TestObserver(c *C) {
+	client := newPipeClient(`{"status":"ok","action":"status"}`, `not json`)
+	observer := new(recordingObserver)
+	client.SetObserver(observer)
+	c.Assert(client.Execute("status"), IsNil)
+	c.Assert(client.Execute("status"), NotNil)
+	c.Assert(observer.events, DeepEquals, []string{
+		"write status",
+		`read {"status":"ok","action":"status"}`,
+		"write status",
+		"read not json",
+		"error not json",
+	})
+}
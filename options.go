@@ -0,0 +1,133 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+)
+
+//ClientOptions holds the settings of a Client so they can be validated,
+//for example at deploy time, before they are applied.
+type ClientOptions struct {
+	//Address of the pubsubsql server in the form host:port,
+	//or any name understood by the Resolver.
+	Address string
+	//Resolver is passed to SetResolver.
+	Resolver Resolver
+	//WriteTimeout is passed to SetWriteTimeout.
+	WriteTimeout time.Duration
+	//ReadTimeoutMultiplier and MinReadTimeout are passed to SetAdaptiveReadTimeout.
+	ReadTimeoutMultiplier float64
+	MinReadTimeout        time.Duration
+	//TablePrefix is passed to SetTablePrefix.
+	TablePrefix string
+	//ReadOnly is passed to SetReadOnly.
+	ReadOnly bool
+	//DualConnection is passed to SetDualConnection.
+	DualConnection bool
+	//TLSConfig is passed to SetTLSConfig.
+	TLSConfig *tls.Config
+}
+
+//Validate checks the options without contacting the pubsubsql server.
+func (o *ClientOptions) Validate() error {
+	if o.Resolver == nil {
+		err := validateAddress(o.Address)
+		if err != nil {
+			return err
+		}
+	} else if o.Address == "" {
+		return errors.New("Invalid address: missing name to resolve")
+	}
+	return o.validateSettings()
+}
+
+func validateAddress(address string) error {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("Invalid address %q: %v", address, err)
+	}
+	if host == "" {
+		return fmt.Errorf("Invalid address %q: missing host", address)
+	}
+	if n, err := strconv.Atoi(port); err != nil || n <= 0 || n > 65535 {
+		return fmt.Errorf("Invalid address %q: invalid port", address)
+	}
+	return nil
+}
+
+func (o *ClientOptions) validateSettings() error {
+	if o.WriteTimeout < 0 {
+		return fmt.Errorf("Invalid write timeout: %v", o.WriteTimeout)
+	}
+	if o.ReadTimeoutMultiplier != 0 && o.ReadTimeoutMultiplier < 1 {
+		return fmt.Errorf("Invalid read timeout multiplier: %v", o.ReadTimeoutMultiplier)
+	}
+	if o.MinReadTimeout < 0 || o.MinReadTimeout > _MAX_READ_TIMEOUT {
+		return fmt.Errorf("Invalid minimum read timeout: %v", o.MinReadTimeout)
+	}
+	if o.TablePrefix != "" && !isIdentifier(o.TablePrefix) {
+		return fmt.Errorf("Invalid table prefix: %q", o.TablePrefix)
+	}
+	return nil
+}
+
+//ApplyOptions validates the options and applies them to the Client.
+//The Client is not connected, call Connect with the Address.
+func (c *Client) ApplyOptions(opts ClientOptions) error {
+	err := opts.Validate()
+	if err != nil {
+		return err
+	}
+	c.SetResolver(opts.Resolver)
+	c.SetWriteTimeout(opts.WriteTimeout)
+	c.SetAdaptiveReadTimeout(opts.ReadTimeoutMultiplier, opts.MinReadTimeout)
+	c.SetTablePrefix(opts.TablePrefix)
+	c.SetReadOnly(opts.ReadOnly)
+	c.SetDualConnection(opts.DualConnection)
+	c.SetTLSConfig(opts.TLSConfig)
+	return nil
+}
+
+//DryRun validates the options, resolves the address and host name and connects to the pubsubsql server
+//to check that it answers a status command, then disconnects.
+//No other command is executed.
+func DryRun(opts ClientOptions) error {
+	err := opts.Validate()
+	if err != nil {
+		return err
+	}
+	client := new(Client)
+	client.ApplyOptions(opts)
+	address, err := client.resolve(opts.Address)
+	if err != nil {
+		return err
+	}
+	if err = validateAddress(address); err != nil {
+		return err
+	}
+	host, _, _ := net.SplitHostPort(address)
+	if _, err = net.LookupHost(host); err != nil {
+		return err
+	}
+	err = client.Connect(opts.Address)
+	if err != nil {
+		return err
+	}
+	defer client.Disconnect()
+	return client.Execute("status")
+}
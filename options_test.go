@@ -0,0 +1,66 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	. "gopkg.in/check.v1"
+	"net"
+	"time"
+)
+
+func (s *TestSuite) TestClientOptionsValidate(c *C) {
+	valid := ClientOptions{Address: "localhost:7777", WriteTimeout: time.Second, ReadTimeoutMultiplier: 3, TablePrefix: "tenantA_"}
+	c.Assert(valid.Validate(), IsNil)
+	invalid := []ClientOptions{
+		{Address: "localhost"},
+		{Address: ":7777"},
+		{Address: "localhost:http"},
+		{Address: "localhost:70000"},
+		{Address: "localhost:7777", WriteTimeout: -1},
+		{Address: "localhost:7777", ReadTimeoutMultiplier: 0.5},
+		{Address: "localhost:7777", MinReadTimeout: time.Hour},
+		{Address: "localhost:7777", TablePrefix: "a b"},
+	}
+	for _, opts := range invalid {
+		c.Assert(opts.Validate(), NotNil, Commentf("%+v", opts))
+	}
+}
+
+func (s *TestSuite) TestApplyOptions(c *C) {
+	client := new(Client)
+	c.Assert(client.ApplyOptions(ClientOptions{Address: "localhost:7777", ReadOnly: true, TablePrefix: "t_"}), IsNil)
+	c.Assert(client.ReadOnly(), Equals, true)
+	c.Assert(client.tablePrefix, Equals, "t_")
+	c.Assert(client.ApplyOptions(ClientOptions{}), NotNil)
+}
+
+func (s *TestSuite) TestDryRun(c *C) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, IsNil)
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		rw := newnetHelper(conn, _CLIENT_DEFAULT_BUFFER_SIZE)
+		defer rw.close()
+		header, _, err := rw.readMessage()
+		if err != nil {
+			return
+		}
+		rw.writeHeaderAndMessage(header.RequestId, []byte(`{"status":"ok","action":"status"}`))
+		rw.readMessage()
+	}()
+	c.Assert(DryRun(ClientOptions{Address: listener.Addr().String()}), IsNil)
+	c.Assert(DryRun(ClientOptions{Address: "localhost"}), NotNil)
+}
@@ -0,0 +1,109 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+)
+
+//ErrOutboxFull is returned by Stream when the Client is disconnected and the store-and-forward buffer is full.
+var ErrOutboxFull = errors.New("Store and forward buffer is full")
+
+// outbox buffers streamed commands while the client is disconnected,
+// first in memory and then in a file framed like the wire protocol
+type outbox struct {
+	limit   int
+	path    string
+	memory  [][]byte
+	spilled int
+}
+
+//EnableStoreAndForward buffers commands streamed while the Client is disconnected or after a write failed,
+//and sends them after the next successful Connect.
+//Up to memoryLimit commands are kept in memory, further commands are appended to the file at path.
+//When path is empty Stream returns ErrOutboxFull once the memory buffer is full.
+func (c *Client) EnableStoreAndForward(memoryLimit int, path string) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	c.outbox = &outbox{limit: memoryLimit, path: path}
+}
+
+//Pending returns the number of streamed commands waiting in the store-and-forward buffer.
+func (c *Client) Pending() int {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if c.outbox == nil {
+		return 0
+	}
+	return len(c.outbox.memory) + c.outbox.spilled
+}
+
+func (this *outbox) push(message []byte) error {
+	// once spilled keep appending to the file to preserve the order
+	if len(this.memory) < this.limit && this.spilled == 0 {
+		this.memory = append(this.memory, message)
+		return nil
+	}
+	if this.path == "" {
+		return ErrOutboxFull
+	}
+	file, err := os.OpenFile(this.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(append(NewHeader(uint32(len(message)), 0).Bytes(), message...))
+	if err != nil {
+		file.Close()
+		return err
+	}
+	this.spilled++
+	return file.Close()
+}
+
+// flush sends the buffered messages in order and stops at the first error,
+// unsent messages stay buffered
+func (this *outbox) flush(send func(message []byte) error) error {
+	for len(this.memory) > 0 {
+		if err := send(this.memory[0]); err != nil {
+			return err
+		}
+		this.memory = this.memory[1:]
+	}
+	if this.spilled == 0 {
+		return nil
+	}
+	data, err := ioutil.ReadFile(this.path)
+	if err != nil {
+		return err
+	}
+	var header Header
+	for len(data) >= HeaderSize {
+		header.Decode(data)
+		end := HeaderSize + int(header.MessageSize)
+		if end > len(data) {
+			break
+		}
+		if err := send(data[HeaderSize:end]); err != nil {
+			// keep the unsent messages
+			if err2 := ioutil.WriteFile(this.path, data, 0600); err2 != nil {
+				return err2
+			}
+			return err
+		}
+		this.spilled--
+		data = data[end:]
+	}
+	this.spilled = 0
+	return os.Remove(this.path)
+}
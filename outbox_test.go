@@ -0,0 +1,52 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"errors"
+	. "gopkg.in/check.v1"
+	"path/filepath"
+)
+
+func (s *TestSuite) TestOutboxSpillAndFlush(c *C) {
+	box := &outbox{limit: 2, path: filepath.Join(c.MkDir(), "outbox")}
+	for _, message := range []string{"a", "b", "c", "d"} {
+		c.Assert(box.push([]byte(message)), IsNil)
+	}
+	c.Assert(box.spilled, Equals, 2)
+
+	var sent []string
+	fail := "d"
+	send := func(message []byte) error {
+		if string(message) == fail {
+			return errors.New("broken")
+		}
+		sent = append(sent, string(message))
+		return nil
+	}
+	c.Assert(box.flush(send), NotNil)
+	c.Assert(sent, DeepEquals, []string{"a", "b", "c"})
+	c.Assert(box.spilled, Equals, 1)
+
+	fail = ""
+	c.Assert(box.flush(send), IsNil)
+	c.Assert(sent, DeepEquals, []string{"a", "b", "c", "d"})
+	c.Assert(box.spilled, Equals, 0)
+}
+
+func (s *TestSuite) TestStreamWhileDisconnected(c *C) {
+	client := new(Client)
+	client.EnableStoreAndForward(1, "")
+	c.Assert(client.Stream("update stocks set bid = 1"), IsNil)
+	c.Assert(client.Stream("update stocks set bid = 2"), Equals, ErrOutboxFull)
+	c.Assert(client.Pending(), Equals, 1)
+}
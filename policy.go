@@ -0,0 +1,82 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"fmt"
+	"regexp"
+)
+
+//CommandPolicy inspects an outgoing command and returns an error to veto it.
+type CommandPolicy func(command string) error
+
+//PolicyError is returned when a command is vetoed by a policy created with DenyPatterns or AllowPatterns.
+type PolicyError struct {
+	Command string
+	Reason  string
+}
+
+func (e *PolicyError) Error() string {
+	return fmt.Sprintf("command denied by policy (%s): %s", e.Reason, e.Command)
+}
+
+//SetCommandPolicy installs a policy that is consulted before every command is sent to the pubsubsql server.
+//Pass nil to remove the policy.
+func (c *Client) SetCommandPolicy(policy CommandPolicy) {
+	c.policy = policy
+}
+
+//DenyPatterns returns a CommandPolicy that vetoes commands matching any of the regular expressions.
+//Patterns are case insensitive, for example `^\s*delete\s+from\s+\w+\s*$` forbids delete without where.
+func DenyPatterns(patterns ...string) (CommandPolicy, error) {
+	regexps, err := compilePatterns(patterns)
+	if err != nil {
+		return nil, err
+	}
+	return func(command string) error {
+		for i, re := range regexps {
+			if re.MatchString(command) {
+				return &PolicyError{Command: command, Reason: "matches " + patterns[i]}
+			}
+		}
+		return nil
+	}, nil
+}
+
+//AllowPatterns returns a CommandPolicy that vetoes commands not matching any of the regular expressions.
+//Patterns are case insensitive.
+func AllowPatterns(patterns ...string) (CommandPolicy, error) {
+	regexps, err := compilePatterns(patterns)
+	if err != nil {
+		return nil, err
+	}
+	return func(command string) error {
+		for _, re := range regexps {
+			if re.MatchString(command) {
+				return nil
+			}
+		}
+		return &PolicyError{Command: command, Reason: "not in allow list"}
+	}, nil
+}
+
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	regexps := make([]*regexp.Regexp, len(patterns))
+	for i, pattern := range patterns {
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			return nil, err
+		}
+		regexps[i] = re
+	}
+	return regexps, nil
+}
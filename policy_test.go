@@ -0,0 +1,36 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+func (s *TestSuite) TestDenyPatterns(c *C) {
+	policy, err := DenyPatterns(`^\s*delete\s+from\s+\w+\s*$`)
+	c.Assert(err, IsNil)
+	client := newPipeClient()
+	client.SetCommandPolicy(policy)
+	err = client.Execute("DELETE from stocks")
+	c.Assert(err, FitsTypeOf, &PolicyError{})
+	c.Assert(client.checkCommand("delete from stocks where ticker = IBM"), IsNil)
+}
+
+func (s *TestSuite) TestAllowPatterns(c *C) {
+	policy, err := AllowPatterns(`^select\s`, `^subscribe\s`)
+	c.Assert(err, IsNil)
+	c.Assert(policy("select * from stocks"), IsNil)
+	c.Assert(policy("insert into stocks (ticker) values (IBM)"), NotNil)
+
+	_, err = AllowPatterns("(")
+	c.Assert(err, NotNil)
+}
@@ -0,0 +1,122 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+//ErrPoolClosed is returned by Get after the Pool was closed.
+var ErrPoolClosed = errors.New("Pool is closed")
+
+//Pool manages up to a fixed number of Clients connected to the same pubsubsql server
+//for request/response workloads, such as web services querying concurrently.
+//A Pool is safe for use by multiple goroutines.
+type Pool struct {
+	opts ClientOptions
+	// one token per Client that may be in use
+	slots  chan struct{}
+	mu     sync.Mutex
+	idle   []*Client
+	closed bool
+}
+
+//NewPool validates the options and returns a Pool of up to size Clients configured with them,
+//connected to opts.Address when first needed.
+func NewPool(opts ClientOptions, size int) (*Pool, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("Invalid pool size: %d", size)
+	}
+	err := opts.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return &Pool{opts: opts, slots: make(chan struct{}, size)}, nil
+}
+
+//Get returns an idle Client or connects a new one, waiting while all Clients are in use until ctx is done.
+//The Client must be returned with Put and must not be used afterwards.
+func (p *Pool) Get(ctx context.Context) (*Client, error) {
+	select {
+	case p.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		<-p.slots
+		return nil, ErrPoolClosed
+	}
+	if n := len(p.idle); n > 0 {
+		c := p.idle[n-1]
+		p.idle = p.idle[:n-1]
+		p.mu.Unlock()
+		return c, nil
+	}
+	p.mu.Unlock()
+	c := new(Client)
+	c.ApplyOptions(p.opts)
+	err := c.ConnectContext(ctx, p.opts.Address)
+	if err != nil {
+		<-p.slots
+		return nil, err
+	}
+	return c, nil
+}
+
+//Put returns the Client taken with Get to the Pool. A Client that lost its connection,
+//also by a done context of a context method, or holds subscriptions is disconnected
+//instead of being handed out again.
+func (p *Pool) Put(c *Client) {
+	p.mu.Lock()
+	reuse := !p.closed && c.Connected() && len(c.subscriptions) == 0
+	if reuse {
+		p.idle = append(p.idle, c)
+	}
+	p.mu.Unlock()
+	if !reuse {
+		c.Disconnect()
+	}
+	<-p.slots
+}
+
+//Query executes the command on a Client of the Pool and reads all rows of its result set.
+//When ctx is done before the response is read, the connection of the Client is closed.
+func (p *Pool) Query(ctx context.Context, command string) (*ResultSet, error) {
+	c, err := p.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer p.Put(c)
+	stop := c.watchConnection(ctx)
+	result, err := c.Query(command)
+	if err = stop(err); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+//Close disconnects the idle Clients, Clients in use are disconnected when they are returned.
+func (p *Pool) Close() {
+	p.mu.Lock()
+	idle := p.idle
+	p.idle = nil
+	p.closed = true
+	p.mu.Unlock()
+	for _, c := range idle {
+		c.Disconnect()
+	}
+}
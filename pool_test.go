@@ -0,0 +1,47 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"context"
+	. "gopkg.in/check.v1"
+	"time"
+)
+
+func (s *TestSuite) TestPool(c *C) {
+	selected := `{"status":"ok","action":"select","rows":1,"fromrow":1,"torow":1,"columns":["id"],"data":[["1"]]}`
+	address := serveConnections(c, []string{selected, selected})
+	_, err := NewPool(ClientOptions{Address: address}, 0)
+	c.Assert(err, NotNil)
+	pool, err := NewPool(ClientOptions{Address: address, ReadOnly: true}, 1)
+	c.Assert(err, IsNil)
+
+	result, err := pool.Query(context.Background(), "select * from stocks")
+	c.Assert(err, IsNil)
+	c.Assert(result.Rows, DeepEquals, [][]string{{"1"}})
+
+	// the only Client is reused and the Pool waits while it is in use
+	client, err := pool.Get(context.Background())
+	c.Assert(err, IsNil)
+	c.Assert(client.ReadOnly(), Equals, true)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = pool.Get(ctx)
+	c.Assert(err, Equals, context.DeadlineExceeded)
+	c.Assert(client.Execute("select * from stocks"), IsNil)
+	pool.Put(client)
+
+	pool.Close()
+	c.Assert(client.Connected(), Equals, false)
+	_, err = pool.Get(context.Background())
+	c.Assert(err, Equals, ErrPoolClosed)
+}
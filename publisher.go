@@ -0,0 +1,261 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+//ErrPublisherClosed is returned by Publish after the Publisher was closed.
+var ErrPublisherClosed = errors.New("Publisher is closed")
+
+//PublisherOptions configures a Publisher.
+type PublisherOptions struct {
+	//BatchSize is the number of rows streamed together, 1 sends every row immediately.
+	BatchSize int
+	//FlushInterval is the longest time a row waits for its batch to fill, zero waits for Flush.
+	FlushInterval time.Duration
+	//Retries is the number of times a failed batch is sent again after reconnecting.
+	Retries int
+	//RetryDelay is the pause before every retry.
+	RetryDelay time.Duration
+	//MemoryLimit and SpillPath enable store-and-forward on the Client when MemoryLimit is positive,
+	//see EnableStoreAndForward.
+	MemoryLimit int
+	SpillPath   string
+	//WriteMode is passed to SetWriteMode of the Client.
+	WriteMode WriteMode
+}
+
+//PublisherStats is a snapshot of the counters of a Publisher.
+type PublisherStats struct {
+	//Published is the number of rows accepted by Publish.
+	Published uint64
+	//Sent is the number of rows streamed to the pubsubsql server or its store-and-forward buffer.
+	Sent uint64
+	//Batches is the number of batches streamed.
+	Batches uint64
+	//Retries is the number of times a batch was sent again.
+	Retries uint64
+	//Failed is the number of rows dropped after the retries were exhausted.
+	Failed uint64
+}
+
+//Publisher streams rows into a table in batches, the producer counterpart of Subscription.
+//Publish may be called from multiple goroutines. The Publisher reconnects the Client to retry
+//failed batches, so it should be the only user of the Client.
+type Publisher struct {
+	client  *Client
+	table   string
+	columns []string
+	opts    PublisherOptions
+	// pending rows and counters
+	mu      sync.Mutex
+	pending [][]string
+	stats   PublisherStats
+	closed  bool
+	// rows sent since the pubsubsql server last confirmed it processed them
+	unconfirmed int
+	// keeps batches in order
+	smu  sync.Mutex
+	stop chan struct{}
+	done chan struct{}
+}
+
+//NewPublisher returns a Publisher inserting rows with the columns into the table through the connected Client.
+func NewPublisher(client *Client, table string, columns []string, opts PublisherOptions) (*Publisher, error) {
+	if _, err := InsertCommands(table, columns, nil); err != nil {
+		return nil, err
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 1
+	}
+	if opts.Retries < 0 {
+		return nil, fmt.Errorf("Invalid number of retries: %d", opts.Retries)
+	}
+	if opts.MemoryLimit > 0 {
+		client.EnableStoreAndForward(opts.MemoryLimit, opts.SpillPath)
+	}
+	client.SetWriteMode(opts.WriteMode)
+	p := &Publisher{
+		client:  client,
+		table:   table,
+		columns: columns,
+		opts:    opts,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	if opts.FlushInterval > 0 {
+		go p.flushEvery(opts.FlushInterval)
+	} else {
+		close(p.done)
+	}
+	return p, nil
+}
+
+//Publish queues a row with a value for every column of the Publisher.
+//The batch is streamed when it is full; errors of batches streamed in the background
+//are delivered to the Errors channel of the Client.
+func (p *Publisher) Publish(values map[string]string) error {
+	if len(values) != len(p.columns) {
+		return fmt.Errorf("Row has %d values for %d columns", len(values), len(p.columns))
+	}
+	row := make([]string, len(p.columns))
+	for i, column := range p.columns {
+		value, ok := values[column]
+		if !ok {
+			return fmt.Errorf("Row has no value for column %s", column)
+		}
+		row[i] = value
+	}
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return ErrPublisherClosed
+	}
+	p.pending = append(p.pending, row)
+	p.stats.Published++
+	full := len(p.pending) >= p.opts.BatchSize
+	p.mu.Unlock()
+	if full {
+		return p.Flush()
+	}
+	return nil
+}
+
+//Flush streams the queued rows.
+func (p *Publisher) Flush() error {
+	_, err := p.flush(context.Background())
+	return err
+}
+
+// flush streams the queued rows and returns the number of rows dropped
+func (p *Publisher) flush(ctx context.Context) (int, error) {
+	p.smu.Lock()
+	defer p.smu.Unlock()
+	p.mu.Lock()
+	rows := p.pending
+	p.pending = nil
+	p.mu.Unlock()
+	if len(rows) == 0 {
+		return 0, nil
+	}
+	commands, err := InsertCommands(p.table, p.columns, rows)
+	if err == nil {
+		err = p.send(ctx, commands)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err != nil {
+		p.stats.Failed += uint64(len(rows))
+		return len(rows), err
+	}
+	p.stats.Sent += uint64(len(rows))
+	p.stats.Batches++
+	p.unconfirmed += len(rows)
+	return 0, nil
+}
+
+// send streams the commands retrying after reconnecting until ctx is done
+func (p *Publisher) send(ctx context.Context, commands []string) error {
+	err := p.client.StreamBatch(commands)
+	for attempt := 0; err != nil && attempt < p.opts.Retries && ctx.Err() == nil; attempt++ {
+		p.mu.Lock()
+		p.stats.Retries++
+		p.mu.Unlock()
+		time.Sleep(p.opts.RetryDelay)
+		if !p.client.Connected() {
+			if err = p.client.Connect(p.client.address); err != nil {
+				continue
+			}
+		}
+		err = p.client.StreamBatch(commands)
+	}
+	return err
+}
+
+func (p *Publisher) flushEvery(interval time.Duration) {
+	defer close(p.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := p.Flush(); err != nil {
+				p.client.report(err)
+			}
+		case <-p.stop:
+			return
+		}
+	}
+}
+
+//Stats returns a snapshot of the counters of the Publisher.
+func (p *Publisher) Stats() PublisherStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.stats
+}
+
+//Close stops the background flush, streams the queued rows and waits until the pubsubsql server
+//confirms it processed every streamed row by answering a status command.
+//Close returns the number of rows that were dropped or could not be confirmed, which includes
+//rows held in the store-and-forward buffer of a disconnected Client.
+//When ctx is done before the confirmation arrives, the connection of the Client is closed.
+func (p *Publisher) Close(ctx context.Context) (int, error) {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return 0, nil
+	}
+	p.closed = true
+	p.mu.Unlock()
+	if p.opts.FlushInterval > 0 {
+		close(p.stop)
+	}
+	<-p.done
+
+	type result struct {
+		dropped int
+		err     error
+	}
+	results := make(chan result, 1)
+	go func() {
+		dropped, err := p.flush(ctx)
+		if err == nil {
+			err = p.client.Execute("status")
+		}
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if err != nil {
+			dropped += p.unconfirmed
+		}
+		p.unconfirmed = 0
+		results <- result{dropped, err}
+	}()
+	select {
+	case r := <-results:
+		return r.dropped, r.err
+	case <-ctx.Done():
+		// unblock the confirmation
+		p.client.interrupt()
+		r := <-results
+		if r.err == nil {
+			return r.dropped, nil
+		}
+		return r.dropped, ctx.Err()
+	}
+}
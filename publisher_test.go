@@ -0,0 +1,105 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"context"
+	. "gopkg.in/check.v1"
+	"net"
+	"time"
+)
+
+// newCaptureClient returns a Client connected to an in-memory server that
+// delivers every received message to the returned channel
+func newCaptureClient() (*Client, <-chan string) {
+	client := new(Client)
+	conn, server := net.Pipe()
+	client.rw.set(conn, _CLIENT_DEFAULT_BUFFER_SIZE)
+	received := make(chan string, 100)
+	go func() {
+		rw := newnetHelper(server, _CLIENT_DEFAULT_BUFFER_SIZE)
+		for {
+			_, bytes, err := rw.readMessage()
+			if err != nil {
+				close(received)
+				return
+			}
+			received <- string(bytes)
+		}
+	}()
+	return client, received
+}
+
+func (s *TestSuite) TestPublisherBatch(c *C) {
+	client, received := newCaptureClient()
+	publisher, err := NewPublisher(client, "ticks", []string{"ticker", "bid"}, PublisherOptions{BatchSize: 2})
+	c.Assert(err, IsNil)
+	c.Assert(publisher.Publish(map[string]string{"ticker": "IBM", "bid": "120"}), IsNil)
+	c.Assert(publisher.Stats().Sent, Equals, uint64(0))
+	c.Assert(publisher.Publish(map[string]string{"ticker": "MSFT", "bid": "37"}), IsNil)
+	c.Assert(<-received, Equals, "stream insert into ticks (ticker,bid) values (IBM,120)")
+	c.Assert(<-received, Equals, "stream insert into ticks (ticker,bid) values (MSFT,37)")
+	c.Assert(publisher.Publish(map[string]string{"ticker": "ORCL", "bid": "40"}), IsNil)
+	c.Assert(publisher.Flush(), IsNil)
+	c.Assert(<-received, Equals, "stream insert into ticks (ticker,bid) values (ORCL,40)")
+	c.Assert(publisher.Stats(), Equals, PublisherStats{Published: 3, Sent: 3, Batches: 2})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	// the capture server never answers the status command
+	dropped, err := publisher.Close(ctx)
+	c.Assert(err, Equals, context.DeadlineExceeded)
+	c.Assert(dropped, Equals, 3)
+	c.Assert(publisher.Publish(map[string]string{"ticker": "IBM", "bid": "121"}), Equals, ErrPublisherClosed)
+}
+
+func (s *TestSuite) TestPublisherInterval(c *C) {
+	client, received := newCaptureClient()
+	publisher, err := NewPublisher(client, "ticks", []string{"ticker"}, PublisherOptions{BatchSize: 10, FlushInterval: time.Millisecond})
+	c.Assert(err, IsNil)
+	c.Assert(publisher.Publish(map[string]string{"ticker": "IBM"}), IsNil)
+	select {
+	case message := <-received:
+		c.Assert(message, Equals, "stream insert into ticks (ticker) values (IBM)")
+	case <-time.After(time.Second):
+		c.Fatal("row was not flushed")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	publisher.Close(ctx)
+}
+
+func (s *TestSuite) TestPublisherClose(c *C) {
+	client := newPipeClient(`{"status":"ok","action":"status"}`)
+	publisher, err := NewPublisher(client, "ticks", []string{"ticker"}, PublisherOptions{BatchSize: 10, FlushInterval: time.Hour})
+	c.Assert(err, IsNil)
+	c.Assert(publisher.Publish(map[string]string{"ticker": "IBM"}), IsNil)
+	c.Assert(publisher.Publish(map[string]string{"ticker": "MSFT"}), IsNil)
+	dropped, err := publisher.Close(context.Background())
+	c.Assert(err, IsNil)
+	c.Assert(dropped, Equals, 0)
+	c.Assert(publisher.Stats().Sent, Equals, uint64(2))
+	dropped, err = publisher.Close(context.Background())
+	c.Assert(dropped, Equals, 0)
+	c.Assert(err, IsNil)
+}
+
+func (s *TestSuite) TestPublisherRetries(c *C) {
+	client := new(Client)
+	publisher, err := NewPublisher(client, "ticks", []string{"ticker"}, PublisherOptions{Retries: 2})
+	c.Assert(err, IsNil)
+	c.Assert(publisher.Publish(map[string]string{"ticker": "IBM"}), NotNil)
+	c.Assert(publisher.Stats(), Equals, PublisherStats{Published: 1, Retries: 2, Failed: 1})
+
+	c.Assert(publisher.Publish(map[string]string{"bid": "1"}), ErrorMatches, "Row has no value for column ticker")
+	_, err = NewPublisher(client, "ticks", nil, PublisherOptions{})
+	c.Assert(err, NotNil)
+}
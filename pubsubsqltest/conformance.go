@@ -0,0 +1,147 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsqltest
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/pubsubsql/client"
+)
+
+//VectorsVersion is the version of the test vectors, incremented whenever a vector changes
+//so other implementations can tell which revision of the reference behavior they pass.
+const VectorsVersion = 1
+
+// the vectors are also read by clients in other languages from testdata/vectors.json
+//go:embed testdata/vectors.json
+var _VECTORS []byte
+
+//Decoded is the result of decoding a frame, compared by Conformance.
+type Decoded struct {
+	RequestId uint32     `json:"requestId"`
+	Action    string     `json:"action"`
+	PubSubId  string     `json:"pubSubId"`
+	RowCount  int        `json:"rowCount"`
+	Columns   []string   `json:"columns"`
+	Rows      [][]string `json:"rows"`
+}
+
+//Vector is a frame as sent by the pubsubsql server with its expected decoding.
+type Vector struct {
+	Name string `json:"name"`
+	//Frame is the hex encoded header and message.
+	Frame    string `json:"frame"`
+	Expected struct {
+		Decoded
+		//Error is true when decoding must fail, including responses reporting a server error.
+		Error bool `json:"error"`
+	} `json:"expected"`
+}
+
+//Decoder decodes a frame the way a client implementation does.
+type Decoder func(frame []byte) (Decoded, error)
+
+//Vectors returns the test vectors of VectorsVersion.
+func Vectors() ([]Vector, error) {
+	var file struct {
+		Version int      `json:"version"`
+		Vectors []Vector `json:"vectors"`
+	}
+	if err := json.Unmarshal(_VECTORS, &file); err != nil {
+		return nil, err
+	}
+	if file.Version != VectorsVersion {
+		return nil, fmt.Errorf("Test vectors version %d, expected %d", file.Version, VectorsVersion)
+	}
+	return file.Vectors, nil
+}
+
+//Conformance decodes every test vector with decode and reports the differences to the reference behavior,
+//for custom transports and ports of the client validating against this package.
+func Conformance(t testing.TB, decode Decoder) {
+	t.Helper()
+	vectors, err := Vectors()
+	if err != nil {
+		t.Fatalf("%v", err)
+		return
+	}
+	for _, vector := range vectors {
+		frame, err := hex.DecodeString(vector.Frame)
+		if err != nil {
+			t.Errorf("%s: %v", vector.Name, err)
+			continue
+		}
+		decoded, err := decode(frame)
+		if vector.Expected.Error {
+			if err == nil {
+				t.Errorf("%s: expected an error, decoded %+v", vector.Name, decoded)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", vector.Name, err)
+			continue
+		}
+		if !reflect.DeepEqual(decoded, vector.Expected.Decoded) {
+			t.Errorf("%s: decoded %+v, expected %+v", vector.Name, decoded, vector.Expected.Decoded)
+		}
+	}
+}
+
+//Decode is the Decoder of this package, it decodes the frame with ReadFrame and the message with a Client.
+func Decode(frame []byte) (Decoded, error) {
+	var decoded Decoded
+	header, message, err := pubsubsql.ReadFrame(bytes.NewReader(frame))
+	if err != nil {
+		return decoded, err
+	}
+	decoded.RequestId = header.RequestId
+	// the message is published to a Client, which decodes it like a response
+	conn, server := net.Pipe()
+	go func() {
+		pubsubsql.WriteFrame(server, 0, message)
+		io.Copy(ioutil.Discard, server)
+	}()
+	client := pubsubsql.NewClientOverConn(conn)
+	defer client.Disconnect()
+	if err = client.WaitForPubSub(1000); err != nil {
+		return decoded, err
+	}
+	decoded.Action = client.Action()
+	decoded.PubSubId = client.PubSubId()
+	decoded.RowCount = client.RowCount()
+	decoded.Columns = client.Columns()
+	for {
+		ok, err := client.NextRow()
+		if err != nil {
+			return decoded, err
+		}
+		if !ok {
+			break
+		}
+		row := make([]string, client.ColumnCount())
+		for i := range row {
+			row[i] = client.ValueByOrdinal(i)
+		}
+		decoded.Rows = append(decoded.Rows, row)
+	}
+	return decoded, nil
+}
@@ -0,0 +1,30 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsqltest
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+func (s *TestSuite) TestConformance(c *C) {
+	t := new(recordingT)
+	Conformance(t, Decode)
+	c.Assert(t.errors, HasLen, 0)
+
+	// a decoder ignoring the columns fails the vectors with rows
+	Conformance(t, func(frame []byte) (Decoded, error) {
+		decoded, err := Decode(frame)
+		decoded.Columns = nil
+		return decoded, err
+	})
+	c.Assert(t.errors, HasLen, 4)
+}
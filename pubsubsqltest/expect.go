@@ -0,0 +1,118 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsqltest
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pubsubsql/client"
+)
+
+//Matcher reports whether the published message current in the Client is the expected one.
+type Matcher func(client *pubsubsql.Client) bool
+
+//Any matches every message.
+func Any() Matcher {
+	return func(client *pubsubsql.Client) bool {
+		return true
+	}
+}
+
+//Action matches messages with the action, such as add, insert, update or delete.
+func Action(action string) Matcher {
+	return func(client *pubsubsql.Client) bool {
+		return client.Action() == action
+	}
+}
+
+//RowWith matches messages with a row whose column has the value.
+//The rows of the message are consumed.
+func RowWith(column string, value string) Matcher {
+	return func(client *pubsubsql.Client) bool {
+		for {
+			more, err := client.NextRow()
+			if err != nil || !more {
+				return false
+			}
+			if client.Value(column) == value {
+				return true
+			}
+		}
+	}
+}
+
+//All matches messages matched by every matcher, evaluated in order.
+func All(matchers ...Matcher) Matcher {
+	return func(client *pubsubsql.Client) bool {
+		for _, matcher := range matchers {
+			if !matcher(client) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+//ExpectMessage waits up to timeout for a message published for the subscription that matches,
+//and fails the test when none arrives. Messages that do not match, including messages of other
+//subscriptions of the Client, are consumed. The matching message stays current in the Client.
+func ExpectMessage(t testing.TB, sub *pubsubsql.Subscription, matcher Matcher, timeout time.Duration) bool {
+	t.Helper()
+	seen, matched := waitFor(sub, matcher, timeout)
+	if matched {
+		return true
+	}
+	t.Errorf("no matching message for subscription %s on %s within %v\nreceived:\n\t%s",
+		sub.PubSubId(), sub.Table(), timeout, strings.Join(seen, "\n\t"))
+	return false
+}
+
+//ExpectNoMessage fails the test when a message published for the subscription that matches
+//arrives within timeout. Messages are consumed.
+func ExpectNoMessage(t testing.TB, sub *pubsubsql.Subscription, matcher Matcher, timeout time.Duration) bool {
+	t.Helper()
+	_, matched := waitFor(sub, matcher, timeout)
+	if !matched {
+		return true
+	}
+	t.Errorf("unexpected message for subscription %s on %s:\n\t%s", sub.PubSubId(), sub.Table(), sub.Client().JSON())
+	return false
+}
+
+// waitFor returns the messages of the subscription received until one matched or timeout expired
+func waitFor(sub *pubsubsql.Subscription, matcher Matcher, timeout time.Duration) ([]string, bool) {
+	client := sub.Client()
+	deadline := time.Now().Add(timeout)
+	var seen []string
+	for {
+		left := deadline.Sub(time.Now())
+		if left <= 0 {
+			return seen, false
+		}
+		if err := client.WaitForPubSub(int((left + time.Millisecond - 1) / time.Millisecond)); err != nil {
+			if !client.Connected() {
+				seen = append(seen, "<"+err.Error()+">")
+				return seen, false
+			}
+			continue
+		}
+		if client.PubSubId() != sub.PubSubId() {
+			continue
+		}
+		seen = append(seen, client.JSON())
+		if matcher(client) {
+			return seen, true
+		}
+	}
+}
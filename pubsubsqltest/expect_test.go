@@ -0,0 +1,40 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsqltest
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *TestSuite) TestExpectMessage(c *C) {
+	client, _ := scriptedClient(c,
+		`{"status":"ok","action":"subscribe","pubsubid":"1"}`,
+		`{"status":"ok","action":"subscribe","pubsubid":"2"}`,
+		`pubsub:{"status":"ok","action":"add","pubsubid":"2","rows":1,"fromrow":1,"torow":1,"columns":["ticker"],"data":[["MSFT"]]}`,
+		`pubsub:{"status":"ok","action":"add","pubsubid":"1","rows":2,"fromrow":1,"torow":2,"columns":["ticker"],"data":[["IBM"],["MCD"]]}`,
+		`pubsub:{"status":"ok","action":"update","pubsubid":"1","rows":1,"fromrow":1,"torow":1,"columns":["ticker"],"data":[["IBM"]]}`,
+	)
+	defer client.Disconnect()
+	stocks, err := client.Subscribe("stocks", nil)
+	c.Assert(err, IsNil)
+	_, err = client.Subscribe("orders", nil)
+	c.Assert(err, IsNil)
+
+	t := new(recordingT)
+	c.Assert(ExpectMessage(t, stocks, All(Action("add"), RowWith("ticker", "MCD")), time.Second), Equals, true)
+	c.Assert(ExpectNoMessage(t, stocks, Action("delete"), 50*time.Millisecond), Equals, true)
+	c.Assert(t.errors, HasLen, 0)
+	c.Assert(ExpectMessage(t, stocks, Any(), 10*time.Millisecond), Equals, false)
+	c.Assert(t.errors, HasLen, 1)
+}
@@ -0,0 +1,248 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsqltest
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/pubsubsql/client"
+)
+
+//MockClient implements pubsubsql.PubSubClient without a pubsubsql server, to unit test code using the Client.
+//Commands succeed with an empty result set unless Respond or Fail scripted them, and published messages
+//queued by Publish are delivered by WaitForPubSub, which returns pubsubsql.ErrTimeout without waiting
+//when none is queued. A MockClient is safe for use by multiple goroutines.
+type MockClient struct {
+	mu        sync.Mutex
+	connected bool
+	results   map[string]*pubsubsql.ResultSet
+	errors    map[string]error
+	published []pubsubsql.Message
+	commands  []string
+	// current result set
+	current  *pubsubsql.ResultSet
+	pubsubId string
+	row      int
+}
+
+var _ pubsubsql.PubSubClient = (*MockClient)(nil)
+
+//NewMockClient returns a connected MockClient.
+func NewMockClient() *MockClient {
+	return &MockClient{
+		connected: true,
+		results:   make(map[string]*pubsubsql.ResultSet),
+		errors:    make(map[string]error),
+		current:   new(pubsubsql.ResultSet),
+	}
+}
+
+//Respond makes every execution of the command return the result set.
+func (m *MockClient) Respond(command string, result *pubsubsql.ResultSet) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.results[command] = result
+	delete(m.errors, command)
+}
+
+//Fail makes every execution of the command fail with the error.
+func (m *MockClient) Fail(command string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.errors[command] = err
+	delete(m.results, command)
+}
+
+//Publish queues a published message for WaitForPubSub.
+func (m *MockClient) Publish(message pubsubsql.Message) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.published = append(m.published, message)
+}
+
+//Commands returns the executed and streamed commands in order.
+func (m *MockClient) Commands() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.commands...)
+}
+
+//Connect marks the MockClient connected, the address is ignored.
+func (m *MockClient) Connect(address string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.connected = true
+	return nil
+}
+
+//Disconnect marks the MockClient disconnected, commands then fail with pubsubsql.ErrNotConnected.
+func (m *MockClient) Disconnect() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.connected = false
+}
+
+//Connected returns true unless Disconnect was called.
+func (m *MockClient) Connected() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.connected
+}
+
+//Execute records the command and makes its scripted result set current.
+func (m *MockClient) Execute(command string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.execute(command)
+}
+
+// execute records the command and makes its scripted result current, mu must be held
+func (m *MockClient) execute(command string) error {
+	if !m.connected {
+		return pubsubsql.ErrNotConnected
+	}
+	m.commands = append(m.commands, command)
+	m.current = new(pubsubsql.ResultSet)
+	m.pubsubId = ""
+	m.row = -1
+	if err := m.errors[command]; err != nil {
+		return err
+	}
+	if result := m.results[command]; result != nil {
+		m.current = result
+	} else if verb := strings.Fields(command); len(verb) > 0 {
+		m.current = &pubsubsql.ResultSet{Action: strings.ToLower(verb[0])}
+	}
+	return nil
+}
+
+//ExecuteArgs binds the arguments with pubsubsql.BindArgs and executes the command.
+func (m *MockClient) ExecuteArgs(command string, args ...interface{}) error {
+	bound, err := pubsubsql.BindArgs(command, args...)
+	if err != nil {
+		return err
+	}
+	return m.Execute(bound)
+}
+
+//Query executes the command and returns a copy of its result set.
+func (m *MockClient) Query(command string) (*pubsubsql.ResultSet, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.execute(command); err != nil {
+		return nil, err
+	}
+	result := *m.current
+	return &result, nil
+}
+
+//Stream records the command prefixed with stream.
+func (m *MockClient) Stream(command string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.connected {
+		return pubsubsql.ErrNotConnected
+	}
+	m.commands = append(m.commands, "stream "+command)
+	return nil
+}
+
+//WaitForPubSub makes the next message queued by Publish current.
+func (m *MockClient) WaitForPubSub(timeout int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.published) == 0 {
+		return pubsubsql.ErrTimeout
+	}
+	message := m.published[0]
+	m.published = m.published[1:]
+	m.current = &message.ResultSet
+	m.pubsubId = message.PubSubId
+	m.row = -1
+	return nil
+}
+
+//PubSubId returns the PubSubId of the current published message.
+func (m *MockClient) PubSubId() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.pubsubId
+}
+
+//Action returns the action of the current result set.
+func (m *MockClient) Action() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.current.Action
+}
+
+//RowCount returns the number of rows of the current result set.
+func (m *MockClient) RowCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.current.Rows)
+}
+
+//NextRow moves to the next row of the current result set.
+func (m *MockClient) NextRow() (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.row+1 >= len(m.current.Rows) {
+		return false, nil
+	}
+	m.row++
+	return true, nil
+}
+
+//Value returns the value of the column in the current row.
+func (m *MockClient) Value(column string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.current.Value(m.row, column)
+}
+
+//ValueByOrdinal returns the value of the column ordinal in the current row.
+func (m *MockClient) ValueByOrdinal(ordinal int) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.row < 0 || m.row >= len(m.current.Rows) || ordinal < 0 || ordinal >= len(m.current.Rows[m.row]) {
+		return ""
+	}
+	return m.current.Rows[m.row][ordinal]
+}
+
+//HasColumn determines if the column exists in the current result set.
+func (m *MockClient) HasColumn(column string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, name := range m.current.Columns {
+		if name == column {
+			return true
+		}
+	}
+	return false
+}
+
+//Columns returns the columns of the current result set.
+func (m *MockClient) Columns() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.current.Columns
+}
+
+//ColumnCount returns the number of columns of the current result set.
+func (m *MockClient) ColumnCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.current.Columns)
+}
@@ -0,0 +1,73 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsqltest
+
+import (
+	"errors"
+
+	"github.com/pubsubsql/client"
+	. "gopkg.in/check.v1"
+)
+
+// tickers is code under test depending only on the interface
+func tickers(client pubsubsql.Querier) ([]string, error) {
+	if err := client.ExecuteArgs("select * from stocks where open = ?", true); err != nil {
+		return nil, err
+	}
+	var tickers []string
+	for {
+		ok, err := client.NextRow()
+		if err != nil || !ok {
+			return tickers, err
+		}
+		tickers = append(tickers, client.Value("ticker"))
+	}
+}
+
+func (s *TestSuite) TestMockClient(c *C) {
+	mock := NewMockClient()
+	mock.Respond("select * from stocks where open = true", &pubsubsql.ResultSet{
+		Action:  "select",
+		Columns: []string{"ticker"},
+		Rows:    [][]string{{"IBM"}, {"MSFT"}},
+	})
+	result, err := tickers(mock)
+	c.Assert(err, IsNil)
+	c.Assert(result, DeepEquals, []string{"IBM", "MSFT"})
+
+	c.Assert(mock.Execute("insert into stocks (ticker) values (ORCL)"), IsNil)
+	c.Assert(mock.Action(), Equals, "insert")
+	mock.Fail("delete from stocks", errors.New("table is locked"))
+	c.Assert(mock.Execute("delete from stocks"), ErrorMatches, "table is locked")
+	c.Assert(mock.Stream("update stocks set bid = 1"), IsNil)
+	c.Assert(mock.Commands(), DeepEquals, []string{
+		"select * from stocks where open = true",
+		"insert into stocks (ticker) values (ORCL)",
+		"delete from stocks",
+		"stream update stocks set bid = 1",
+	})
+
+	c.Assert(mock.WaitForPubSub(10), Equals, pubsubsql.ErrTimeout)
+	mock.Publish(pubsubsql.Message{
+		ResultSet: pubsubsql.ResultSet{Action: "add", Columns: []string{"ticker"}, Rows: [][]string{{"IBM"}}},
+		PubSubId:  "1",
+	})
+	c.Assert(mock.WaitForPubSub(10), IsNil)
+	c.Assert(mock.PubSubId(), Equals, "1")
+	ok, err := mock.NextRow()
+	c.Assert(err, IsNil)
+	c.Assert(ok, Equals, true)
+	c.Assert(mock.ValueByOrdinal(0), Equals, "IBM")
+
+	mock.Disconnect()
+	c.Assert(mock.Execute("status"), Equals, pubsubsql.ErrNotConnected)
+}
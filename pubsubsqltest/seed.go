@@ -0,0 +1,161 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+//Package pubsubsqltest provides utilities for tests of applications using pubsubsql.
+package pubsubsqltest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/pubsubsql/client"
+)
+
+//Table declares the setup and the rows of a table seeded by Seed.
+type Table struct {
+	Name    string     `json:"name"`
+	Key     string     `json:"key"`
+	Tags    []string   `json:"tags"`
+	Columns []string   `json:"columns"`
+	Rows    [][]string `json:"rows"`
+}
+
+//Fixture declares the tables seeded by Seed.
+type Fixture struct {
+	Tables []Table `json:"tables"`
+}
+
+//LoadFixture reads a fixture from a JSON file such as
+//	{"tables": [{"name": "stocks", "key": "ticker", "columns": ["ticker", "bid"], "rows": [["IBM", "120"]]}]}
+func LoadFixture(path string) (Fixture, error) {
+	var fixture Fixture
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fixture, err
+	}
+	err = json.Unmarshal(data, &fixture)
+	if err != nil {
+		return fixture, fmt.Errorf("%s: %v", path, err)
+	}
+	return fixture, nil
+}
+
+//Seed declares the key and tags of every table of the fixture and inserts its rows.
+func Seed(client *pubsubsql.Client, fixture Fixture) error {
+	for _, table := range fixture.Tables {
+		if table.Key != "" {
+			if err := client.Execute("key " + table.Name + " " + table.Key); err != nil {
+				return fmt.Errorf("%s key: %v", table.Name, err)
+			}
+		}
+		for _, tag := range table.Tags {
+			if err := client.Execute("tag " + table.Name + " " + tag); err != nil {
+				return fmt.Errorf("%s tag: %v", table.Name, err)
+			}
+		}
+		if len(table.Rows) == 0 {
+			continue
+		}
+		if err := client.InsertRows(table.Name, table.Columns, table.Rows); err != nil {
+			return fmt.Errorf("%s: %v", table.Name, err)
+		}
+	}
+	return nil
+}
+
+//Rows returns all rows of the table as maps from column to value.
+func Rows(client *pubsubsql.Client, table string) ([]map[string]string, error) {
+	err := client.Execute("select * from " + table)
+	if err != nil {
+		return nil, err
+	}
+	var rows []map[string]string
+	for {
+		more, err := client.NextRow()
+		if err != nil {
+			return nil, err
+		}
+		if !more {
+			return rows, nil
+		}
+		row := make(map[string]string)
+		for _, column := range client.Columns() {
+			row[column] = client.Value(column)
+		}
+		rows = append(rows, row)
+	}
+}
+
+//AssertRows fails the test unless the table holds exactly the wanted rows in any order.
+//Only the columns present in the wanted rows are compared, so generated columns such as id can be left out.
+func AssertRows(t testing.TB, client *pubsubsql.Client, table string, want []map[string]string) bool {
+	t.Helper()
+	rows, err := Rows(client, table)
+	if err != nil {
+		t.Errorf("select from %s: %v", table, err)
+		return false
+	}
+	// wanted rows not matched yet by their formatted values
+	unmatched := make(map[string]int)
+	for _, row := range want {
+		unmatched[formatRow(row, row)]++
+	}
+	var unexpected []string
+	for _, row := range rows {
+		matched := false
+		for _, wanted := range want {
+			projected := formatRow(row, wanted)
+			if unmatched[projected] > 0 {
+				unmatched[projected]--
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			unexpected = append(unexpected, formatRow(row, row))
+		}
+	}
+	var missing []string
+	for row, count := range unmatched {
+		for ; count > 0; count-- {
+			missing = append(missing, row)
+		}
+	}
+	if len(missing) == 0 && len(unexpected) == 0 {
+		return true
+	}
+	sort.Strings(missing)
+	t.Errorf("table %s does not hold the wanted rows\nmissing:\n\t%s\nunexpected:\n\t%s",
+		table, strings.Join(missing, "\n\t"), strings.Join(unexpected, "\n\t"))
+	return false
+}
+
+// formatRow formats the values of the row for the columns of the pattern in sorted order
+func formatRow(row map[string]string, pattern map[string]string) string {
+	columns := make([]string, 0, len(pattern))
+	for column := range pattern {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	fields := make([]string, len(columns))
+	for i, column := range columns {
+		value, ok := row[column]
+		if !ok {
+			value = "<missing>"
+		}
+		fields[i] = column + "=" + value
+	}
+	return "{" + strings.Join(fields, " ") + "}"
+}
@@ -0,0 +1,128 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsqltest
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pubsubsql/client"
+	. "gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type TestSuite struct{}
+
+var _ = Suite(&TestSuite{})
+
+// scriptedClient returns a Client connected to a server answering every request with the next response
+// and the channel receiving the requests. Responses starting with "pubsub:" are published after the
+// server reads the next request, just before its reply, or at the end of the script.
+func scriptedClient(c *C, responses ...string) (*pubsubsql.Client, <-chan string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, IsNil)
+	requests := make(chan string, len(responses))
+	go func() {
+		defer listener.Close()
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		var published []string
+		for _, response := range responses {
+			if strings.HasPrefix(response, "pubsub:") {
+				published = append(published, response[len("pubsub:"):])
+				continue
+			}
+			header, message, err := pubsubsql.ReadFrame(conn)
+			if err != nil {
+				return
+			}
+			requests <- string(message)
+			for _, message := range published {
+				pubsubsql.WriteFrame(conn, 0, []byte(message))
+			}
+			published = nil
+			pubsubsql.WriteFrame(conn, header.RequestId, []byte(response))
+		}
+		for _, message := range published {
+			pubsubsql.WriteFrame(conn, 0, []byte(message))
+		}
+	}()
+	client := new(pubsubsql.Client)
+	c.Assert(client.Connect(listener.Addr().String()), IsNil)
+	return client, requests
+}
+
+// recordingT captures the failures reported to testing.TB
+type recordingT struct {
+	testing.TB
+	errors []string
+}
+
+func (t *recordingT) Helper() {}
+
+func (t *recordingT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, format)
+}
+
+func (s *TestSuite) TestSeed(c *C) {
+	path := filepath.Join(c.MkDir(), "fixture.json")
+	err := ioutil.WriteFile(path, []byte(`{"tables": [{"name": "stocks", "key": "ticker", "tags": ["sector"],
+		"columns": ["ticker", "sector"], "rows": [["IBM", "tech"], ["MCD", "food"]]}]}`), 0600)
+	c.Assert(err, IsNil)
+	fixture, err := LoadFixture(path)
+	c.Assert(err, IsNil)
+	client, requests := scriptedClient(c,
+		`{"status":"ok","action":"key"}`,
+		`{"status":"ok","action":"tag"}`,
+		`{"status":"ok","action":"insert","id":"1"}`,
+		`{"status":"ok","action":"insert","id":"2"}`,
+	)
+	defer client.Disconnect()
+	c.Assert(Seed(client, fixture), IsNil)
+	for _, want := range []string{
+		"key stocks ticker",
+		"tag stocks sector",
+		"insert into stocks (ticker,sector) values (IBM,tech)",
+		"insert into stocks (ticker,sector) values (MCD,food)",
+	} {
+		c.Assert(<-requests, Equals, want)
+	}
+
+	_, err = LoadFixture(filepath.Join(c.MkDir(), "missing.json"))
+	c.Assert(os.IsNotExist(err), Equals, true)
+}
+
+func (s *TestSuite) TestAssertRows(c *C) {
+	selected := `{"status":"ok","action":"select","rows":2,"fromrow":1,"torow":2,"columns":["id","ticker","bid"],"data":[["1","IBM","120"],["2","MCD","90"]]}`
+	client, _ := scriptedClient(c, selected, selected)
+	defer client.Disconnect()
+
+	t := new(recordingT)
+	c.Assert(AssertRows(t, client, "stocks", []map[string]string{
+		{"ticker": "MCD", "bid": "90"},
+		{"ticker": "IBM"},
+	}), Equals, true)
+	c.Assert(t.errors, HasLen, 0)
+
+	c.Assert(AssertRows(t, client, "stocks", []map[string]string{
+		{"ticker": "IBM", "bid": "121"},
+	}), Equals, false)
+	c.Assert(t.errors, HasLen, 1)
+}
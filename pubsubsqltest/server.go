@@ -0,0 +1,238 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsqltest
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pubsubsql/client"
+)
+
+//Reply is a scripted answer of the Server to one request, with optional faults.
+type Reply struct {
+	//JSON is the response, ignored when Disconnect is set.
+	JSON string
+	//Delay postpones the response.
+	Delay time.Duration
+	//Partial writes the header and only half of the response, then closes the connection.
+	Partial bool
+	//Disconnect closes the connection instead of responding.
+	Disconnect bool
+}
+
+//Server is an in-process pubsubsql server on a loopback listener that speaks the wire protocol
+//and answers requests with scripted replies, for deterministic tests of client behavior.
+//Requests without a scripted reply are answered with status ok and the verb of the command as action;
+//streamed commands are never answered. A Server is safe for use by multiple goroutines.
+type Server struct {
+	listener  net.Listener
+	requests  chan string
+	mu        sync.Mutex
+	replies   []Reply
+	conns     map[*serverConn]bool
+	readDelay time.Duration
+	wg        sync.WaitGroup
+}
+
+// serverConn serializes the writes to one connection
+type serverConn struct {
+	conn net.Conn
+	mu   sync.Mutex
+}
+
+//NewServer starts a Server on a free loopback port.
+func NewServer() (*Server, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		listener: listener,
+		requests: make(chan string, 1024),
+		conns:    make(map[*serverConn]bool),
+	}
+	s.wg.Add(1)
+	go s.accept()
+	return s, nil
+}
+
+//Address returns the address to connect the Client to.
+func (s *Server) Address() string {
+	return s.listener.Addr().String()
+}
+
+//Requests returns the channel receiving every request, streamed ones included, in the order they were read.
+//Requests are dropped once 1024 are waiting.
+func (s *Server) Requests() <-chan string {
+	return s.requests
+}
+
+//Respond queues responses for the next requests.
+func (s *Server) Respond(responses ...string) {
+	for _, response := range responses {
+		s.Reply(Reply{JSON: response})
+	}
+}
+
+//Reply queues a reply for the next request.
+func (s *Server) Reply(reply Reply) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.replies = append(s.replies, reply)
+}
+
+//SetReadDelay makes the Server pause before reading every request, so a Client writing many
+//commands fills the connection buffers and experiences backpressure.
+func (s *Server) SetReadDelay(delay time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.readDelay = delay
+}
+
+//Publish sends the published message to every connected Client.
+func (s *Server) Publish(message string) {
+	s.mu.Lock()
+	conns := make([]*serverConn, 0, len(s.conns))
+	for conn := range s.conns {
+		conns = append(conns, conn)
+	}
+	s.mu.Unlock()
+	for _, conn := range conns {
+		conn.write(0, []byte(message))
+	}
+}
+
+//Connections returns the number of connected Clients.
+func (s *Server) Connections() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.conns)
+}
+
+//DisconnectAll closes the connections of all Clients, the Server keeps accepting new ones.
+func (s *Server) DisconnectAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for conn := range s.conns {
+		conn.conn.Close()
+	}
+}
+
+//Close stops the Server and closes all connections.
+func (s *Server) Close() {
+	s.listener.Close()
+	s.DisconnectAll()
+	s.wg.Wait()
+}
+
+func (s *Server) accept() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		sc := &serverConn{conn: conn}
+		s.mu.Lock()
+		s.conns[sc] = true
+		s.mu.Unlock()
+		s.wg.Add(1)
+		go s.serve(sc)
+	}
+}
+
+// serve answers the requests of one connection
+func (s *Server) serve(sc *serverConn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, sc)
+		s.mu.Unlock()
+		sc.conn.Close()
+	}()
+	for {
+		s.mu.Lock()
+		delay := s.readDelay
+		s.mu.Unlock()
+		if delay > 0 {
+			time.Sleep(delay)
+		}
+		header, message, err := pubsubsql.ReadFrame(sc.conn)
+		if err != nil {
+			return
+		}
+		command := string(message)
+		select {
+		case s.requests <- command:
+		default:
+		}
+		verb := strings.ToLower(firstField(command))
+		if verb == "stream" || verb == "close" {
+			if verb == "close" {
+				return
+			}
+			continue
+		}
+		reply := s.next(verb)
+		if reply.Delay > 0 {
+			time.Sleep(reply.Delay)
+		}
+		switch {
+		case reply.Disconnect:
+			return
+		case reply.Partial:
+			sc.writePartial(header.RequestId, []byte(reply.JSON))
+			return
+		default:
+			if sc.write(header.RequestId, []byte(reply.JSON)) != nil {
+				return
+			}
+		}
+	}
+}
+
+// next pops the scripted reply or composes the default one
+func (s *Server) next(verb string) Reply {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.replies) == 0 {
+		return Reply{JSON: fmt.Sprintf(`{"status":"ok","action":%q}`, verb)}
+	}
+	reply := s.replies[0]
+	s.replies = s.replies[1:]
+	return reply
+}
+
+func (this *serverConn) write(requestId uint32, message []byte) error {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	return pubsubsql.WriteFrame(this.conn, requestId, message)
+}
+
+func (this *serverConn) writePartial(requestId uint32, message []byte) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	this.conn.Write(pubsubsql.NewHeader(uint32(len(message)), requestId).Bytes())
+	this.conn.Write(message[:len(message)/2])
+}
+
+func firstField(command string) string {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
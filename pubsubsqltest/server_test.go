@@ -0,0 +1,71 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsqltest
+
+import (
+	"time"
+
+	"github.com/pubsubsql/client"
+	. "gopkg.in/check.v1"
+)
+
+func (s *TestSuite) TestServer(c *C) {
+	server, err := NewServer()
+	c.Assert(err, IsNil)
+	defer server.Close()
+	server.Respond(`{"status":"ok","action":"subscribe","pubsubid":"1"}`)
+
+	client := new(pubsubsql.Client)
+	c.Assert(client.Connect(server.Address()), IsNil)
+	c.Assert(client.Execute("subscribe * from stocks"), IsNil)
+	c.Assert(<-server.Requests(), Equals, "subscribe * from stocks")
+	c.Assert(client.Execute("status"), IsNil)
+	c.Assert(client.Action(), Equals, "status")
+	<-server.Requests()
+
+	server.Publish(`{"status":"ok","action":"add","pubsubid":"1","rows":1,"fromrow":1,"torow":1,"columns":["ticker"],"data":[["IBM"]]}`)
+	c.Assert(client.WaitForPubSub(1000), IsNil)
+	ok, err := client.NextRow()
+	c.Assert(err, IsNil)
+	c.Assert(ok, Equals, true)
+	c.Assert(client.Value("ticker"), Equals, "IBM")
+
+	c.Assert(client.Stream("insert into stocks (ticker) values (MSFT)"), IsNil)
+	c.Assert(<-server.Requests(), Equals, "stream insert into stocks (ticker) values (MSFT)")
+	c.Assert(server.Connections(), Equals, 1)
+	client.Disconnect()
+}
+
+func (s *TestSuite) TestServerFaults(c *C) {
+	server, err := NewServer()
+	c.Assert(err, IsNil)
+	defer server.Close()
+
+	client := new(pubsubsql.Client)
+	c.Assert(client.Connect(server.Address()), IsNil)
+	server.Reply(Reply{Disconnect: true})
+	c.Assert(client.Execute("status"), NotNil)
+	c.Assert(client.Connected(), Equals, false)
+
+	c.Assert(client.Connect(server.Address()), IsNil)
+	server.Reply(Reply{JSON: `{"status":"ok","action":"status"}`, Partial: true})
+	c.Assert(client.Execute("status"), NotNil)
+
+	c.Assert(client.Connect(server.Address()), IsNil)
+	server.Reply(Reply{JSON: `{"status":"ok","action":"status"}`, Delay: 50 * time.Millisecond})
+	start := time.Now()
+	c.Assert(client.Execute("status"), IsNil)
+	c.Assert(time.Since(start) >= 50*time.Millisecond, Equals, true)
+
+	server.DisconnectAll()
+	c.Assert(client.Execute("status"), NotNil)
+}
@@ -0,0 +1,68 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+//ResultSet holds the complete response of a command read by Query.
+type ResultSet struct {
+	Action  string
+	Columns []string
+	Rows    [][]string
+}
+
+//Value returns the value of the column in the row with the zero based index,
+//or an empty string when the row or column does not exist.
+func (r *ResultSet) Value(row int, column string) string {
+	if row < 0 || row >= len(r.Rows) {
+		return ""
+	}
+	for ordinal, name := range r.Columns {
+		if name == column && ordinal < len(r.Rows[row]) {
+			return r.Rows[row][ordinal]
+		}
+	}
+	return ""
+}
+
+//Query executes the command and reads all rows of its result set.
+//Unlike Execute, Query may be called from multiple goroutines: the calls are serialized
+//and the returned ResultSet does not change with later commands.
+func (c *Client) Query(command string) (*ResultSet, error) {
+	c.qmu.Lock()
+	defer c.qmu.Unlock()
+	err := c.Execute(command)
+	if err != nil {
+		return nil, err
+	}
+	return c.readResultSet()
+}
+
+// readResultSet reads the rows of the current response
+func (c *Client) readResultSet() (*ResultSet, error) {
+	result := &ResultSet{
+		Action:  c.Action(),
+		Columns: append([]string(nil), c.Columns()...),
+	}
+	for {
+		ok, err := c.NextRow()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return result, nil
+		}
+		row := make([]string, len(result.Columns))
+		for i := range row {
+			row[i] = c.ValueByOrdinal(i)
+		}
+		result.Rows = append(result.Rows, row)
+	}
+}
@@ -0,0 +1,47 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	. "gopkg.in/check.v1"
+	"sync"
+)
+
+func (s *TestSuite) TestQueryConcurrent(c *C) {
+	const queries = 8
+	response := `{"status":"ok","action":"select","rows":2,"fromrow":1,"torow":2,"columns":["id","ticker"],"data":[["1","IBM"],["2","MSFT"]]}`
+	responses := make([]string, queries)
+	for i := range responses {
+		responses[i] = response
+	}
+	client := newPipeClient(responses...)
+	client.SetDebug(true)
+	results := make(chan *ResultSet, queries)
+	var wg sync.WaitGroup
+	for i := 0; i < queries; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := client.Query("select * from stocks")
+			c.Check(err, IsNil)
+			results <- result
+		}()
+	}
+	wg.Wait()
+	close(results)
+	for result := range results {
+		c.Assert(result.Action, Equals, "select")
+		c.Assert(result.Rows, DeepEquals, [][]string{{"1", "IBM"}, {"2", "MSFT"}})
+		c.Assert(result.Value(1, "ticker"), Equals, "MSFT")
+		c.Assert(result.Value(2, "ticker"), Equals, "")
+	}
+}
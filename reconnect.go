@@ -0,0 +1,118 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"context"
+	"time"
+)
+
+//ReconnectHandler is called after the Client reconnected on its own, with the replayed subscriptions,
+//whose PubSubIds changed, and the error of the first subscription that could not be replayed.
+//Messages published while the Client was disconnected are lost, the handler may reconcile
+//the state of the application, for example by selecting the subscribed rows.
+//The handler is called synchronously and may execute commands.
+type ReconnectHandler func(subscriptions []*Subscription, err error)
+
+// delay after the first failed attempt when ReconnectOptions.MinDelay is not set
+var _DEFAULT_RECONNECT_DELAY = 100 * time.Millisecond
+
+//ReconnectOptions configures the automatic reconnection of a Client.
+type ReconnectOptions struct {
+	//MinDelay is the delay after the first failed attempt, doubled after every further one up to MaxDelay.
+	//Zero means 100 milliseconds.
+	MinDelay time.Duration
+	MaxDelay time.Duration
+	//Attempts limits the attempts of one reconnection, zero retries until the Client reconnects
+	//or the context of ExecuteContext or WaitForPubSubContext is done.
+	Attempts int
+	//OnReconnect is called after every automatic reconnection.
+	OnReconnect ReconnectHandler
+}
+
+//SetAutoReconnect makes the Client reconnect to the pubsubsql server after its connection was closed
+//by a failure rather than by Disconnect. Execute and WaitForPubSub reconnect before they send or wait,
+//and WaitForPubSub keeps waiting when the connection fails during the wait.
+//The active subscriptions are replayed by executing their subscribe commands again.
+//The command pending when the connection failed is not retried since the pubsubsql server may have executed it.
+//Pass nil to disable the automatic reconnection.
+func (c *Client) SetAutoReconnect(opts *ReconnectOptions) {
+	c.reconnectOpts = opts
+}
+
+// reconnectable returns true when the connection was closed by a failure and auto reconnect is enabled
+func (c *Client) reconnectable() bool {
+	if c.reconnectOpts == nil || c.reconnecting || c.rw.valid() || c.address == "" {
+		return false
+	}
+	return c.closeReason != CloseNone && c.closeReason != CloseUser
+}
+
+// reconnect reconnects with backoff and replays the subscriptions when the connection was closed by a failure,
+// it gives up with ctx.Err() when ctx is done
+func (c *Client) reconnect(ctx context.Context) error {
+	if !c.reconnectable() {
+		return nil
+	}
+	opts := c.reconnectOpts
+	c.reconnecting = true
+	defer func() { c.reconnecting = false }()
+	delay := opts.MinDelay
+	if delay <= 0 {
+		delay = _DEFAULT_RECONNECT_DELAY
+	}
+	for attempt := 1; ; attempt++ {
+		err := c.open(ctx)
+		if err == nil {
+			break
+		}
+		if opts.Attempts > 0 && attempt >= opts.Attempts {
+			return err
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+		delay *= 2
+		if opts.MaxDelay > 0 && delay > opts.MaxDelay {
+			delay = opts.MaxDelay
+		}
+	}
+	subscriptions, err := c.replay()
+	if opts.OnReconnect != nil {
+		opts.OnReconnect(subscriptions, err)
+	}
+	return nil
+}
+
+// replay executes the subscribe commands of the active subscriptions again,
+// subscriptions that fail are kept with their old PubSubId to be replayed by the next reconnection
+func (c *Client) replay() ([]*Subscription, error) {
+	subscriptions := c.sortedSubscriptions()
+	c.subscriptions = nil
+	replayed := make([]*Subscription, 0, len(subscriptions))
+	var first error
+	for _, s := range subscriptions {
+		if err := s.subscribe(); err != nil {
+			if first == nil {
+				first = err
+			}
+			c.addSubscription(s)
+			continue
+		}
+		replayed = append(replayed, s)
+	}
+	return replayed, first
+}
@@ -0,0 +1,126 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"context"
+	. "gopkg.in/check.v1"
+	"net"
+	"time"
+)
+
+// serveConnections answers the requests of the nth accepted connection with the nth script
+// and closes it, published messages are sent after the reply they follow
+func serveConnections(c *C, scripts ...[]string) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, IsNil)
+	go func() {
+		defer listener.Close()
+		for _, script := range scripts {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			for _, response := range script {
+				if len(response) > 7 && response[:7] == "pubsub:" {
+					WriteFrame(conn, 0, []byte(response[7:]))
+					continue
+				}
+				header, _, err := ReadFrame(conn)
+				if err != nil {
+					break
+				}
+				WriteFrame(conn, header.RequestId, []byte(response))
+			}
+			conn.Close()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func (s *TestSuite) TestAutoReconnect(c *C) {
+	address := serveConnections(c,
+		[]string{`{"status":"ok","action":"subscribe","pubsubid":"1"}`},
+		[]string{
+			`{"status":"ok","action":"subscribe","pubsubid":"5"}`,
+			`pubsub:{"status":"ok","action":"add","pubsubid":"5"}`,
+			`{"status":"ok","action":"status"}`,
+		},
+	)
+	var replayed []*Subscription
+	client := new(Client)
+	client.SetAutoReconnect(&ReconnectOptions{
+		MinDelay: time.Millisecond,
+		OnReconnect: func(subscriptions []*Subscription, err error) {
+			c.Assert(err, IsNil)
+			replayed = subscriptions
+		},
+	})
+	c.Assert(client.Connect(address), IsNil)
+	sub, err := client.Subscribe("stocks", nil)
+	c.Assert(err, IsNil)
+
+	// the server closes the first connection, the wait continues on the second
+	c.Assert(client.WaitForPubSub(1000), IsNil)
+	c.Assert(client.CloseReason(), Equals, CloseNone)
+	c.Assert(replayed, DeepEquals, []*Subscription{sub})
+	c.Assert(sub.PubSubId(), Equals, "5")
+	c.Assert(client.Subscription("1"), IsNil)
+	c.Assert(client.Execute("status"), IsNil)
+
+	// no reconnection after Disconnect
+	client.Disconnect()
+	c.Assert(client.Execute("status"), Equals, ErrNotConnected)
+}
+
+func (s *TestSuite) TestAutoReconnectAttempts(c *C) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, IsNil)
+	address := listener.Addr().String()
+	go func() {
+		conn, err := listener.Accept()
+		listener.Close()
+		if err == nil {
+			conn.Close()
+		}
+	}()
+	client := new(Client)
+	client.SetAutoReconnect(&ReconnectOptions{MinDelay: time.Millisecond, Attempts: 2})
+	c.Assert(client.Connect(address), IsNil)
+	c.Assert(client.Execute("status"), NotNil)
+	c.Assert(client.CloseReason(), Not(Equals), CloseNone)
+	// the listener is gone, both attempts fail
+	c.Assert(client.Execute("status"), ErrorMatches, ".*refused.*")
+}
+
+func (s *TestSuite) TestAutoReconnectContext(c *C) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, IsNil)
+	address := listener.Addr().String()
+	go func() {
+		conn, err := listener.Accept()
+		listener.Close()
+		if err == nil {
+			conn.Close()
+		}
+	}()
+	client := new(Client)
+	// no MinDelay and no limit on the attempts, the default delay paces the attempts
+	client.SetAutoReconnect(&ReconnectOptions{})
+	c.Assert(client.Connect(address), IsNil)
+	c.Assert(client.Execute("status"), NotNil)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	c.Assert(client.ExecuteContext(ctx, "status"), Equals, context.DeadlineExceeded)
+	c.Assert(time.Since(start) < time.Second, Equals, true)
+}
@@ -0,0 +1,161 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+const _REDACTED = "[redacted]"
+
+//Redactor returns the value written in place of a sensitive value.
+type Redactor func(value string) string
+
+//Redact is a Redactor that replaces every value with [redacted].
+func Redact(value string) string {
+	return _REDACTED
+}
+
+//SetRedaction registers the redactor applied to the values of the column, in any table,
+//before commands and responses are written to the sampled payload log or the response history.
+//While any rule is registered, a command or response that can not be parsed is replaced entirely.
+//A nil redactor removes the rule of the column.
+func (c *Client) SetRedaction(column string, redactor Redactor) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	// the rules are replaced rather than modified since they are read without the lock
+	current := c.redactionRules()
+	redactions := make(map[string]Redactor, len(current)+1)
+	for name, r := range current {
+		redactions[name] = r
+	}
+	if redactor == nil {
+		delete(redactions, column)
+	} else {
+		redactions[column] = redactor
+	}
+	if len(redactions) == 0 {
+		redactions = nil
+	}
+	c.redactions.Store(redactions)
+}
+
+// redactionRules returns the registered redaction rules, nil when there are none
+func (c *Client) redactionRules() map[string]Redactor {
+	redactions, _ := c.redactions.Load().(map[string]Redactor)
+	return redactions
+}
+
+// redactCommand applies the redaction rules to the values of the command
+func (c *Client) redactCommand(command []byte) []byte {
+	redactions := c.redactionRules()
+	if redactions == nil {
+		return command
+	}
+	text := string(command)
+	tokens, err := tokenize(text)
+	if err != nil {
+		return []byte(_REDACTED)
+	}
+	columns := valueColumns(tokens, commandVerb(text) == "insert")
+	for i := len(tokens) - 1; i >= 0; i-- {
+		redactor := redactions[columns[i]]
+		if redactor == nil {
+			continue
+		}
+		t := tokens[i]
+		text = text[:t.start] + quoteValue(redactor(t.text)) + text[t.end:]
+	}
+	return []byte(text)
+}
+
+// valueColumns returns for every token the column it is a value of or an empty string
+func valueColumns(tokens []token, insert bool) []string {
+	columns := make([]string, len(tokens))
+	isValue := func(t token) bool {
+		return t.quoted || isIdentifier(t.text)
+	}
+	if insert {
+		// insert into table (columns) values (values)
+		var names []string
+		i := findToken(tokens, "(", 0)
+		for i++; i < len(tokens) && tokens[i].text != ")"; i++ {
+			if isValue(tokens[i]) {
+				names = append(names, tokens[i].text)
+			}
+		}
+		for i = findToken(tokens, "values", i) + 1; i < len(tokens) && len(names) > 0; i++ {
+			if isValue(tokens[i]) {
+				columns[i] = names[0]
+				names = names[1:]
+			}
+		}
+		return columns
+	}
+	// column operator value in set and where clauses
+	for i := 1; i+1 < len(tokens); i++ {
+		operator := tokens[i]
+		if operator.quoted || isValue(operator) || strings.ContainsAny(operator.text, ",()*") {
+			continue
+		}
+		if !tokens[i-1].quoted && isIdentifier(tokens[i-1].text) && isValue(tokens[i+1]) {
+			columns[i+1] = tokens[i-1].text
+		}
+	}
+	return columns
+}
+
+// findToken returns the index of the first unquoted word at or after from or the number of tokens
+func findToken(tokens []token, word string, from int) int {
+	for i := from; i < len(tokens); i++ {
+		if !tokens[i].quoted && strings.ToLower(tokens[i].text) == word {
+			return i
+		}
+	}
+	return len(tokens)
+}
+
+// redactResponse applies the redaction rules to the data of the JSON response
+func (c *Client) redactResponse(message []byte) []byte {
+	redactions := c.redactionRules()
+	if redactions == nil {
+		return message
+	}
+	var response map[string]interface{}
+	if json.Unmarshal(message, &response) != nil {
+		return []byte(_REDACTED)
+	}
+	columns, _ := response["columns"].([]interface{})
+	data, _ := response["data"].([]interface{})
+	for ordinal, column := range columns {
+		name, _ := column.(string)
+		redactor := redactions[name]
+		if redactor == nil {
+			continue
+		}
+		for _, row := range data {
+			values, _ := row.([]interface{})
+			if ordinal >= len(values) {
+				continue
+			}
+			if value, ok := values[ordinal].(string); ok {
+				values[ordinal] = redactor(value)
+			}
+		}
+	}
+	redacted, err := json.Marshal(response)
+	if err != nil {
+		return []byte(_REDACTED)
+	}
+	return redacted
+}
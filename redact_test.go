@@ -0,0 +1,61 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"bytes"
+	. "gopkg.in/check.v1"
+	"log"
+	"strings"
+)
+
+func (s *TestSuite) TestRedactCommand(c *C) {
+	client := new(Client)
+	c.Assert(string(client.redactCommand([]byte("insert into t (ssn) values (1)"))), Equals, "insert into t (ssn) values (1)")
+	client.SetRedaction("ssn", Redact)
+	client.SetRedaction("name", func(value string) string { return value[:1] + "." })
+	tests := []struct {
+		command  string
+		redacted string
+	}{
+		{"insert into t (id, ssn, name) values (1, '123 45', Smith)", "insert into t (id, ssn, name) values (1, '[redacted]', S.)"},
+		{"stream update t set ssn = 12, bid = 3 where name = 'O''Neil'", "stream update t set ssn = '[redacted]', bid = 3 where name = O."},
+		{"select * from t where id = 1", "select * from t where id = 1"},
+		{"select * from t where ssn = 'unterminated", "[redacted]"},
+	}
+	for _, test := range tests {
+		c.Assert(string(client.redactCommand([]byte(test.command))), Equals, test.redacted)
+	}
+	client.SetRedaction("ssn", nil)
+	client.SetRedaction("name", nil)
+	c.Assert(client.redactionRules(), IsNil)
+}
+
+func (s *TestSuite) TestRedactResponse(c *C) {
+	response := `{"status":"ok","action":"select","rows":1,"fromrow":1,"torow":1,"columns":["id","ssn"],"data":[["1","123"]]}`
+	client := newPipeClient(response)
+	client.SetRedaction("ssn", Redact)
+	client.SetResponseHistory(1)
+	var buffer bytes.Buffer
+	client.SetPayloadSampling(1, 0, log.New(&buffer, "", 0))
+	c.Assert(client.Execute("insert into t (id, ssn) values (1, 123)"), IsNil)
+	// the response itself is not changed
+	ok, err := client.NextRow()
+	c.Assert(ok, Equals, true)
+	c.Assert(err, IsNil)
+	c.Assert(client.Value("ssn"), Equals, "123")
+
+	c.Assert(client.RecentResponses()[0], Not(Matches), ".*123.*")
+	c.Assert(client.RecentResponses()[0], Matches, `.*"data":\[\["1","\[redacted\]"\]\].*`)
+	c.Assert(strings.Contains(buffer.String(), "123"), Equals, false)
+	c.Assert(string(client.redactResponse([]byte("not json"))), Equals, "[redacted]")
+}
@@ -0,0 +1,178 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+//LogLevel selects the messages the Client writes to the standard logger.
+type LogLevel int32
+
+const (
+	//LogTrace logs every step of waiting for published messages, the default.
+	LogTrace LogLevel = iota
+	//LogQuiet logs only sampled payloads, see SetPayloadSampling.
+	LogQuiet
+)
+
+//RuntimeOptions are the settings UpdateOptions changes while the Client is connected.
+//Only the settings that are not nil are changed, the others keep the values set before,
+//for example by NewClient options or setters.
+type RuntimeOptions struct {
+	//WriteTimeout is passed to SetWriteTimeout.
+	WriteTimeout *time.Duration
+	//WriteMode is passed to SetWriteMode.
+	WriteMode *WriteMode
+	//ReadTimeoutMultiplier and MinReadTimeout are passed to SetAdaptiveReadTimeout.
+	ReadTimeoutMultiplier *float64
+	MinReadTimeout        *time.Duration
+	//MaxBacklog limits the published messages kept while waiting for a response,
+	//the oldest message is dropped and reported as a DroppedError when the backlog is full.
+	//Zero keeps every message.
+	MaxBacklog *int
+	//LogLevel selects the messages written to the standard logger.
+	LogLevel *LogLevel
+	//Debug is passed to SetDebug.
+	Debug *bool
+}
+
+//Validate checks the options that are set.
+func (o *RuntimeOptions) Validate() error {
+	var settings ClientOptions
+	if o.WriteTimeout != nil {
+		settings.WriteTimeout = *o.WriteTimeout
+	}
+	if o.ReadTimeoutMultiplier != nil {
+		settings.ReadTimeoutMultiplier = *o.ReadTimeoutMultiplier
+	}
+	if o.MinReadTimeout != nil {
+		settings.MinReadTimeout = *o.MinReadTimeout
+	}
+	if err := settings.validateSettings(); err != nil {
+		return err
+	}
+	if o.WriteMode != nil && (*o.WriteMode < LatencyMode || *o.WriteMode > AdaptiveMode) {
+		return fmt.Errorf("Invalid write mode: %d", *o.WriteMode)
+	}
+	if o.MaxBacklog != nil && *o.MaxBacklog < 0 {
+		return fmt.Errorf("Invalid maximum backlog: %d", *o.MaxBacklog)
+	}
+	if o.LogLevel != nil && (*o.LogLevel < LogTrace || *o.LogLevel > LogQuiet) {
+		return fmt.Errorf("Invalid log level: %d", *o.LogLevel)
+	}
+	return nil
+}
+
+// merge sets the options that are set in other
+func (o *RuntimeOptions) merge(other *RuntimeOptions) {
+	if other.ReadTimeoutMultiplier != nil {
+		o.ReadTimeoutMultiplier = other.ReadTimeoutMultiplier
+	}
+	if other.MinReadTimeout != nil {
+		o.MinReadTimeout = other.MinReadTimeout
+	}
+	if other.MaxBacklog != nil {
+		o.MaxBacklog = other.MaxBacklog
+	}
+	if other.Debug != nil {
+		o.Debug = other.Debug
+	}
+}
+
+//UpdateOptions validates the options and applies the ones that are set without reconnecting.
+//It may be called from any goroutine, for example to tune a Client during an incident:
+//the write settings and log level apply immediately, the others when the next
+//Execute, NextRow or WaitForPubSub call starts.
+func (c *Client) UpdateOptions(opts RuntimeOptions) error {
+	err := opts.Validate()
+	if err != nil {
+		return err
+	}
+	c.wmu.Lock()
+	if opts.WriteTimeout != nil {
+		c.writeTimeout = *opts.WriteTimeout
+	}
+	if opts.WriteMode != nil && c.pacing.mode != *opts.WriteMode {
+		c.pacing = writePacing{mode: *opts.WriteMode, throughput: *opts.WriteMode == ThroughputMode}
+		c.applyWriteModeLocked()
+	}
+	c.wmu.Unlock()
+	c.omu.Lock()
+	if c.pending == nil {
+		c.pending = new(RuntimeOptions)
+	}
+	c.pending.merge(&opts)
+	c.omu.Unlock()
+	if opts.LogLevel != nil {
+		atomic.StoreInt32((*int32)(&c.logLevel), int32(*opts.LogLevel))
+	}
+	return nil
+}
+
+// applyPending applies the options left by UpdateOptions for the reading goroutine
+func (c *Client) applyPending() {
+	c.omu.Lock()
+	opts := c.pending
+	c.pending = nil
+	c.omu.Unlock()
+	if opts == nil {
+		return
+	}
+	if opts.ReadTimeoutMultiplier != nil || opts.MinReadTimeout != nil {
+		multiplier, minTimeout := c.cadence.multiplier, c.cadence.minTimeout
+		if opts.ReadTimeoutMultiplier != nil {
+			multiplier = *opts.ReadTimeoutMultiplier
+		}
+		if opts.MinReadTimeout != nil {
+			minTimeout = *opts.MinReadTimeout
+		}
+		c.SetAdaptiveReadTimeout(multiplier, minTimeout)
+	}
+	if opts.MaxBacklog != nil {
+		c.maxBacklog = *opts.MaxBacklog
+	}
+	if opts.Debug != nil {
+		c.debug = *opts.Debug
+	}
+}
+
+// trace logs a step at the trace log level
+func (c *Client) trace(format string, args ...interface{}) {
+	if LogLevel(atomic.LoadInt32((*int32)(&c.logLevel))) != LogTrace {
+		return
+	}
+	if c.logger != nil {
+		c.logger.Printf(format, args...)
+	} else {
+		log.Printf(format, args...)
+	}
+}
+
+// pushBacklog keeps a published message received while waiting for a response,
+// dropping the oldest message when the backlog is full
+func (c *Client) pushBacklog(message backlogMessage) {
+	if c.maxBacklog > 0 && c.backlog.Len() >= c.maxBacklog {
+		dropped, _ := c.popBacklog()
+		var envelope struct{ PubSubId string }
+		json.Unmarshal(dropped.bytes, &envelope)
+		if s := c.subscriptions[envelope.PubSubId]; s != nil {
+			s.stats.Dropped++
+		}
+		c.report(&DroppedError{PubSubId: envelope.PubSubId, Age: since(dropped.received)})
+	}
+	c.backlog.PushBack(message)
+}
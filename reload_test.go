@@ -0,0 +1,72 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	. "gopkg.in/check.v1"
+	"time"
+)
+
+func (s *TestSuite) TestUpdateOptions(c *C) {
+	client := newPipeClient(
+		`{"status":"ok","action":"subscribe","pubsubid":"1"}`,
+		`pubsub:{"status":"ok","action":"add","pubsubid":"1","rows":1,"fromrow":1,"torow":1,"columns":["id"],"data":[["1"]]}`,
+		`pubsub:{"status":"ok","action":"add","pubsubid":"1","rows":1,"fromrow":1,"torow":1,"columns":["id"],"data":[["2"]]}`,
+		`{"status":"ok","action":"status"}`,
+	)
+	negative, mode, multiplier := -1, WriteMode(7), 0.5
+	c.Assert(client.UpdateOptions(RuntimeOptions{MaxBacklog: &negative}), ErrorMatches, "Invalid maximum backlog.*")
+	c.Assert(client.UpdateOptions(RuntimeOptions{WriteMode: &mode}), ErrorMatches, "Invalid write mode.*")
+	c.Assert(client.UpdateOptions(RuntimeOptions{ReadTimeoutMultiplier: &multiplier}), ErrorMatches, "Invalid read timeout multiplier.*")
+
+	c.Assert(client.Execute("subscribe * from stocks"), IsNil)
+	backlog, quiet, timeout := 1, LogQuiet, time.Second
+	c.Assert(client.UpdateOptions(RuntimeOptions{MaxBacklog: &backlog, LogLevel: &quiet, WriteTimeout: &timeout}), IsNil)
+	c.Assert(client.writeTimeout, Equals, time.Second)
+	// the second published message pushes the first out of the backlog
+	c.Assert(client.Execute("status"), IsNil)
+	c.Assert(client.maxBacklog, Equals, 1)
+	c.Assert(client.Subscription("1").Dropped(), Equals, uint64(1))
+	var dropped *DroppedError
+	select {
+	case err := <-client.Errors():
+		dropped, _ = err.(*DroppedError)
+	default:
+	}
+	c.Assert(dropped, NotNil)
+	c.Assert(dropped.PubSubId, Equals, "1")
+
+	c.Assert(client.WaitForPubSub(1000), IsNil)
+	ok, err := client.NextRow()
+	c.Assert(err, IsNil)
+	c.Assert(ok, Equals, true)
+	c.Assert(client.Value("id"), Equals, "2")
+}
+
+func (s *TestSuite) TestUpdateOptionsPartial(c *C) {
+	client := newPipeClient(`{"status":"ok","action":"status"}`)
+	client.SetWriteTimeout(time.Second)
+	client.SetAdaptiveReadTimeout(3, time.Second)
+	backlog := 5
+	c.Assert(client.UpdateOptions(RuntimeOptions{MaxBacklog: &backlog}), IsNil)
+	debug := true
+	c.Assert(client.UpdateOptions(RuntimeOptions{Debug: &debug}), IsNil)
+	minTimeout := 2 * time.Second
+	c.Assert(client.UpdateOptions(RuntimeOptions{MinReadTimeout: &minTimeout}), IsNil)
+	c.Assert(client.Execute("status"), IsNil)
+	// only the settings passed changed, also when several updates are pending
+	c.Assert(client.writeTimeout, Equals, time.Second)
+	c.Assert(client.maxBacklog, Equals, 5)
+	c.Assert(client.debug, Equals, true)
+	c.Assert(client.cadence.multiplier, Equals, 3.0)
+	c.Assert(client.cadence.minTimeout, Equals, 2*time.Second)
+}
@@ -0,0 +1,30 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+//Resolver translates the address passed to Connect, for example a logical service name,
+//into the host:port address that is dialed.
+type Resolver func(address string) (string, error)
+
+//SetResolver installs the resolver used by Connect.
+//Pass nil to dial the address as is.
+func (c *Client) SetResolver(resolver Resolver) {
+	c.resolver = resolver
+}
+
+// resolve returns the address to dial for the address passed to Connect
+func (c *Client) resolve(address string) (string, error) {
+	if c.resolver == nil {
+		return address, nil
+	}
+	return c.resolver(address)
+}
@@ -0,0 +1,47 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"errors"
+	. "gopkg.in/check.v1"
+	"net"
+)
+
+func (s *TestSuite) TestResolver(c *C) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, IsNil)
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			conn.Close()
+		}
+	}()
+	var resolved []string
+	client := new(Client)
+	client.SetResolver(func(address string) (string, error) {
+		resolved = append(resolved, address)
+		if address != "orders" {
+			return "", errors.New("unknown service")
+		}
+		return listener.Addr().String(), nil
+	})
+	c.Assert(client.Connect("orders"), IsNil)
+	c.Assert(client.Connected(), Equals, true)
+	client.Disconnect()
+	c.Assert(client.Connect("billing"), ErrorMatches, "unknown service")
+	c.Assert(resolved, DeepEquals, []string{"orders", "billing"})
+
+	opts := ClientOptions{Address: "orders", Resolver: func(string) (string, error) { return "", nil }}
+	c.Assert(opts.Validate(), IsNil)
+}
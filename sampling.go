@@ -0,0 +1,72 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"log"
+	"math/rand"
+	"sync"
+)
+
+// payloadSampler logs a random sample of the frames exchanged with the server
+type payloadSampler struct {
+	rate    int
+	maxSize int
+	logger  *log.Logger
+	// frames are sampled from the writing and the reading goroutines
+	mu     sync.Mutex
+	random *rand.Rand
+}
+
+//SetPayloadSampling logs one in rate of the frames written and read by the Client, chosen at random,
+//with the payload truncated to maxSize bytes when maxSize is positive.
+//The standard logger is used when logger is nil. A rate of zero disables the sampling.
+func (c *Client) SetPayloadSampling(rate int, maxSize int, logger *log.Logger) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if rate <= 0 {
+		c.sampler = nil
+		return
+	}
+	c.sampler = &payloadSampler{
+		rate:    rate,
+		maxSize: maxSize,
+		logger:  logger,
+		random:  rand.New(rand.NewSource(_NOW().UnixNano())),
+	}
+}
+
+// pick decides at random whether a frame is logged
+func (this *payloadSampler) pick() bool {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	return this.random.Intn(this.rate) == 0
+}
+
+// write logs the frame, tag is the correlation tag of a sent command
+func (this *payloadSampler) write(direction string, requestId uint32, tag string, message []byte) {
+	payload := message
+	suffix := ""
+	if this.maxSize > 0 && len(payload) > this.maxSize {
+		payload = payload[:this.maxSize]
+		suffix = "... (truncated)"
+	}
+	if tag != "" {
+		direction += " [" + tag + "]"
+	}
+	format := "pubsubsql: %s request %d (%d bytes): %s%s"
+	if this.logger == nil {
+		log.Printf(format, direction, requestId, len(message), payload, suffix)
+		return
+	}
+	this.logger.Printf(format, direction, requestId, len(message), payload, suffix)
+}
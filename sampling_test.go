@@ -0,0 +1,36 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"bytes"
+	. "gopkg.in/check.v1"
+	"log"
+	"strings"
+)
+
+func (s *TestSuite) TestPayloadSampling(c *C) {
+	client := newPipeClient(`{"status":"ok","action":"status"}`, `{"status":"ok","action":"status"}`)
+	var buffer bytes.Buffer
+	client.SetPayloadSampling(1, 10, log.New(&buffer, "", 0))
+	c.Assert(client.Execute("status"), IsNil)
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	c.Assert(lines, DeepEquals, []string{
+		"pubsubsql: sent request 1 (6 bytes): status",
+		`pubsubsql: received request 1 (33 bytes): {"status":... (truncated)`,
+	})
+
+	buffer.Reset()
+	client.SetPayloadSampling(0, 0, log.New(&buffer, "", 0))
+	c.Assert(client.Execute("status"), IsNil)
+	c.Assert(buffer.Len(), Equals, 0)
+}
@@ -0,0 +1,124 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	_TIME_TYPE     = reflect.TypeOf(time.Time{})
+	_DURATION_TYPE = reflect.TypeOf(time.Duration(0))
+)
+
+//ScanStruct copies the values of the current row into the fields of the struct dest points to.
+//A field receives the column named by its pubsubsql tag, or else the column matching its name
+//case-insensitively; fields tagged "-", unexported fields and fields without a column are left unchanged.
+//Values are converted to string, integer, floating point, bool, time.Duration and time.Time fields,
+//times in the RFC 3339 format. A value that cannot be converted is reported as a ValueError.
+func (c *Client) ScanStruct(dest interface{}) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return errors.New("ScanStruct requires a non nil pointer to a struct")
+	}
+	v = v.Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		column := c.fieldColumn(field)
+		if column == "" {
+			continue
+		}
+		value := c.Value(column)
+		if err := setField(v.Field(i), value); err != nil {
+			return &ValueError{Column: column, Value: value, Type: field.Type.String(), Err: err}
+		}
+	}
+	return nil
+}
+
+// fieldColumn returns the column of the result set scanned into the field or an empty string
+func (c *Client) fieldColumn(field reflect.StructField) string {
+	if tag, ok := field.Tag.Lookup("pubsubsql"); ok {
+		if tag == "-" || !c.HasColumn(tag) {
+			return ""
+		}
+		return tag
+	}
+	if c.HasColumn(field.Name) {
+		return field.Name
+	}
+	for _, column := range c.response.Columns {
+		if strings.EqualFold(column, field.Name) {
+			return column
+		}
+	}
+	return ""
+}
+
+// setField converts the value to the type of the field
+func setField(field reflect.Value, value string) error {
+	if field.Type() == _TIME_TYPE {
+		t, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(t))
+		return nil
+	}
+	if field.Type() == _DURATION_TYPE {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	default:
+		return errors.New("unsupported field type")
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	. "gopkg.in/check.v1"
+	"time"
+)
+
+type scannedStock struct {
+	Ticker  string
+	Bid     float64 `pubsubsql:"bid"`
+	Qty     uint32  `pubsubsql:"quantity"`
+	Open    bool
+	Updated time.Time `pubsubsql:"updated_at"`
+	Note    string    `pubsubsql:"-"`
+	Missing int
+}
+
+func (s *TestSuite) TestScanStruct(c *C) {
+	client := newPipeClient(`{"status":"ok","action":"select","rows":2,"fromrow":1,"torow":2,"columns":["ticker","bid","quantity","open","updated_at","Note"],"data":[["IBM","140.5","12","1","2014-03-01T10:00:00Z","x"],["MSFT","30","-1","0","","x"]]}`)
+	c.Assert(client.Execute("select * from stocks"), IsNil)
+	ok, err := client.NextRow()
+	c.Assert(err, IsNil)
+	c.Assert(ok, Equals, true)
+
+	stock := scannedStock{Note: "kept", Missing: 7}
+	c.Assert(client.ScanStruct(&stock), IsNil)
+	c.Assert(stock, DeepEquals, scannedStock{
+		Ticker:  "IBM",
+		Bid:     140.5,
+		Qty:     12,
+		Open:    true,
+		Updated: time.Date(2014, 3, 1, 10, 0, 0, 0, time.UTC),
+		Note:    "kept",
+		Missing: 7,
+	})
+
+	ok, err = client.NextRow()
+	c.Assert(ok, Equals, true)
+	err = client.ScanStruct(&stock)
+	c.Assert(err, ErrorMatches, `Value "-1" of column quantity is not a valid uint32: .*`)
+	c.Assert(client.ScanStruct(stock), ErrorMatches, "ScanStruct requires .*")
+}
@@ -0,0 +1,171 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"time"
+)
+
+// number of power of two buckets in a Histogram
+const _HISTOGRAM_BUCKETS = 40
+
+//Histogram counts values in power of two buckets.
+//Buckets[0] counts zero values and Buckets[i] counts values in [2^(i-1), 2^i).
+type Histogram struct {
+	Count   uint64
+	Sum     uint64
+	Min     uint64
+	Max     uint64
+	Buckets [_HISTOGRAM_BUCKETS]uint64
+}
+
+func (this *Histogram) add(value uint64) {
+	if this.Count == 0 || value < this.Min {
+		this.Min = value
+	}
+	if value > this.Max {
+		this.Max = value
+	}
+	this.Count++
+	this.Sum += value
+	bucket := 0
+	for v := value; v > 0 && bucket < _HISTOGRAM_BUCKETS-1; v >>= 1 {
+		bucket++
+	}
+	this.Buckets[bucket]++
+}
+
+//Mean returns the average of the counted values.
+func (this *Histogram) Mean() float64 {
+	if this.Count == 0 {
+		return 0
+	}
+	return float64(this.Sum) / float64(this.Count)
+}
+
+//Quantile returns an upper bound of the value below which the fraction q of the counted values fall,
+//for example Quantile(0.99) for the 99th percentile.
+func (this *Histogram) Quantile(q float64) uint64 {
+	if this.Count == 0 {
+		return 0
+	}
+	rank := uint64(q * float64(this.Count))
+	var seen uint64
+	for i, count := range this.Buckets {
+		seen += count
+		if seen > rank || seen == this.Count {
+			if i == 0 {
+				return 0
+			}
+			bound := uint64(1)<<uint(i) - 1
+			if bound > this.Max {
+				bound = this.Max
+			}
+			return bound
+		}
+	}
+	return this.Max
+}
+
+//ClientStats is a snapshot of the message metrics of a Client.
+type ClientStats struct {
+	//MessageSize counts the payload sizes in bytes of the received messages.
+	MessageSize Histogram
+	//DecodeTime counts the time in microseconds spent decoding the received messages.
+	DecodeTime Histogram
+	//Tables breaks the traffic down by table name, without the table prefix.
+	Tables map[string]TableStats
+}
+
+//TableStats counts the traffic of a table.
+type TableStats struct {
+	//Commands is the number of executed and streamed commands.
+	Commands uint64
+	//Rows is the number of rows received in responses and published messages.
+	Rows uint64
+	//PubSubMessages is the number of published messages received.
+	PubSubMessages uint64
+	//BytesSent and BytesReceived are the message sizes without the headers.
+	BytesSent     uint64
+	BytesReceived uint64
+}
+
+//MetricsObserver may be implemented by an Observer to receive the metrics of every decoded message.
+type MetricsObserver interface {
+	OnDecode(size int, duration time.Duration)
+}
+
+//Stats returns a snapshot of the message metrics of the Client.
+func (c *Client) Stats() ClientStats {
+	c.smu.Lock()
+	defer c.smu.Unlock()
+	stats := c.stats
+	stats.Tables = make(map[string]TableStats, len(c.tables))
+	for table, t := range c.tables {
+		stats.Tables[table] = *t
+	}
+	return stats
+}
+
+// recordDecode updates the metrics of a decoded message
+func (c *Client) recordDecode(size int, duration time.Duration) {
+	c.smu.Lock()
+	c.stats.MessageSize.add(uint64(size))
+	c.stats.DecodeTime.add(uint64(duration / time.Microsecond))
+	c.smu.Unlock()
+	if observer, ok := c.observer.(MetricsObserver); ok {
+		observer.OnDecode(size, duration)
+	}
+}
+
+// recordSent counts a command sent for the table
+func (c *Client) recordSent(table string, size int) {
+	if table == "" {
+		return
+	}
+	c.smu.Lock()
+	defer c.smu.Unlock()
+	t := c.tableStats(table)
+	t.Commands++
+	t.BytesSent += uint64(size)
+}
+
+// recordReceived counts the decoded response or published message for its table
+func (c *Client) recordReceived(size int) {
+	table := c.rowTable()
+	if table == "" {
+		return
+	}
+	c.smu.Lock()
+	defer c.smu.Unlock()
+	t := c.tableStats(table)
+	t.BytesReceived += uint64(size)
+	if c.response.Fromrow > 0 && c.response.Torow >= c.response.Fromrow {
+		t.Rows += uint64(c.response.Torow - c.response.Fromrow + 1)
+	}
+	if c.responseId == 0 && c.batch == 0 {
+		t.PubSubMessages++
+	}
+}
+
+// tableStats returns the counters of the table, smu must be held
+func (c *Client) tableStats(table string) *TableStats {
+	if c.tables == nil {
+		c.tables = make(map[string]*TableStats)
+	}
+	t := c.tables[table]
+	if t == nil {
+		t = new(TableStats)
+		c.tables[table] = t
+	}
+	return t
+}
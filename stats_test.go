@@ -0,0 +1,87 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	. "gopkg.in/check.v1"
+	"time"
+)
+
+type metricsObserver struct {
+	recordingObserver
+	sizes []int
+}
+
+func (o *metricsObserver) OnDecode(size int, duration time.Duration) {
+	o.sizes = append(o.sizes, size)
+}
+
+func (s *TestSuite) TestHistogram(c *C) {
+	var h Histogram
+	c.Assert(h.Quantile(0.5), Equals, uint64(0))
+	for _, value := range []uint64{0, 1, 2, 3, 100, 1000} {
+		h.add(value)
+	}
+	c.Assert(h.Count, Equals, uint64(6))
+	c.Assert(h.Min, Equals, uint64(0))
+	c.Assert(h.Max, Equals, uint64(1000))
+	c.Assert(h.Buckets[0], Equals, uint64(1))
+	c.Assert(h.Buckets[1], Equals, uint64(1))
+	c.Assert(h.Buckets[2], Equals, uint64(2))
+	c.Assert(h.Buckets[7], Equals, uint64(1))
+	c.Assert(h.Buckets[10], Equals, uint64(1))
+	c.Assert(h.Mean(), Equals, float64(1106)/6)
+	c.Assert(h.Quantile(0.5), Equals, uint64(3))
+	c.Assert(h.Quantile(1), Equals, uint64(1000))
+}
+
+func (s *TestSuite) TestStats(c *C) {
+	client := newPipeClient(`{"status":"ok","action":"status"}`, `not json`)
+	observer := new(metricsObserver)
+	client.SetObserver(observer)
+	c.Assert(client.Execute("status"), IsNil)
+	c.Assert(client.Execute("status"), NotNil)
+	stats := client.Stats()
+	c.Assert(stats.MessageSize.Count, Equals, uint64(2))
+	c.Assert(stats.MessageSize.Max, Equals, uint64(len(`{"status":"ok","action":"status"}`)))
+	c.Assert(stats.DecodeTime.Count, Equals, uint64(2))
+	c.Assert(observer.sizes, DeepEquals, []int{33, 8})
+}
+
+func (s *TestSuite) TestTableStats(c *C) {
+	select1 := `{"status":"ok","action":"select","rows":2,"fromrow":1,"torow":2,"columns":["id"],"data":[["1"],["2"]]}`
+	publish := `{"status":"ok","action":"add","pubsubid":"1","rows":1,"fromrow":1,"torow":1,"columns":["id"],"data":[["3"]]}`
+	client := newPipeClient(
+		select1,
+		`{"status":"ok","action":"subscribe","pubsubid":"1"}`,
+		`pubsub:`+publish,
+	)
+	client.SetTablePrefix("tenant_")
+	c.Assert(client.Execute("select * from stocks"), IsNil)
+	c.Assert(client.Execute("subscribe * from orders"), IsNil)
+	c.Assert(client.WaitForPubSub(1000), IsNil)
+	stats := client.Stats()
+	c.Assert(stats.Tables, HasLen, 2)
+	c.Assert(stats.Tables["stocks"], Equals, TableStats{
+		Commands:      1,
+		Rows:          2,
+		BytesSent:     uint64(len("select * from tenant_stocks")),
+		BytesReceived: uint64(len(select1)),
+	})
+	orders := stats.Tables["orders"]
+	c.Assert(orders.Commands, Equals, uint64(1))
+	c.Assert(orders.Rows, Equals, uint64(1))
+	c.Assert(orders.PubSubMessages, Equals, uint64(1))
+	// the snapshot is not changed by later traffic
+	stats.Tables["stocks"] = TableStats{}
+	c.Assert(client.Stats().Tables["stocks"].Commands, Equals, uint64(1))
+}
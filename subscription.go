@@ -0,0 +1,437 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+//Subscription tracks a subscription created by executing a subscribe command on the Client.
+type Subscription struct {
+	client   *Client
+	command  string
+	table    string
+	pubsubId string
+	paused   bool
+	maxAge   time.Duration
+	stats    SubscriptionStats
+	// heartbeat expectation
+	heartbeat time.Duration
+	onStale   StaleHandler
+	heard     time.Time
+	stale     bool
+	// messages received for the subscription while another subscription was read with Next
+	backlog list.List
+	// rewrite published messages before delivery
+	transforms []Transform
+}
+
+//SubscriptionStats is a snapshot of the delivery statistics of a Subscription.
+type SubscriptionStats struct {
+	//Delivered is the number of messages returned by WaitForPubSub.
+	Delivered uint64
+	//Dropped is the number of messages dropped because they exceeded the maximum age.
+	Dropped uint64
+	//Conflated is the number of messages merged into a later message of the subscription.
+	//Neither the Client nor the pubsubsql server merges published messages, every message
+	//is delivered or dropped on its own, so Conflated is always zero.
+	Conflated uint64
+	//Lag is the time the last delivered message waited in the Client before it was delivered.
+	Lag time.Duration
+	//LastMessage is the time the last message was delivered.
+	LastMessage time.Time
+}
+
+//PubSubId returns the identifier the pubsubsql server assigned to the subscription.
+//The identifier changes when a paused subscription is resumed.
+func (s *Subscription) PubSubId() string {
+	return s.pubsubId
+}
+
+//Client returns the Client that receives the published messages of the subscription.
+func (s *Subscription) Client() *Client {
+	return s.client
+}
+
+//Table returns the name of the subscribed table.
+func (s *Subscription) Table() string {
+	return s.table
+}
+
+//Command returns the subscribe command that created the subscription.
+func (s *Subscription) Command() string {
+	return s.command
+}
+
+//Paused returns true if the subscription is paused.
+func (s *Subscription) Paused() bool {
+	return s.paused
+}
+
+//Active returns true while the subscription receives published messages,
+//that is until it is paused or removed.
+func (s *Subscription) Active() bool {
+	return !s.paused && s.client.subscriptions[s.pubsubId] == s
+}
+
+//Backlog returns the number of messages waiting to be returned by Next.
+func (s *Subscription) Backlog() int {
+	return s.backlog.Len()
+}
+
+//Next returns the next message published for the subscription, waiting up to timeout milliseconds.
+//Messages published meanwhile for other subscriptions of the Client are kept in their own backlogs
+//and are returned by their Next, not by WaitForPubSub.
+func (s *Subscription) Next(timeout int) (*Message, error) {
+	if element := s.backlog.Front(); element != nil {
+		s.backlog.Remove(element)
+		message := element.Value.(Message)
+		return &message, nil
+	}
+	c := s.client
+	deadline := _NOW().Add(time.Duration(timeout) * time.Millisecond)
+	for {
+		remaining := int(deadline.Sub(_NOW()) / time.Millisecond)
+		if remaining <= 0 {
+			return nil, ErrTimeout
+		}
+		if err := c.WaitForPubSub(remaining); err != nil {
+			return nil, err
+		}
+		message, err := c.message()
+		if err != nil {
+			return nil, err
+		}
+		if message.PubSubId == s.pubsubId {
+			return &message, nil
+		}
+		if other := c.subscriptions[message.PubSubId]; other != nil {
+			other.backlog.PushBack(message)
+		}
+	}
+}
+
+//Unsubscribe removes the subscription from the pubsubsql server and discards its backlog.
+//A paused subscription is only discarded since it is not known to the pubsubsql server.
+//Like Pause and Resume, it may be called while SubscribeChan delivers messages, it then waits
+//until the delivery goroutine releases the Client.
+func (s *Subscription) Unsubscribe() error {
+	s.client.pmu.Lock()
+	defer s.client.pmu.Unlock()
+	if s.Active() {
+		if err := s.unsubscribe(); err != nil {
+			return err
+		}
+	}
+	s.paused = false
+	s.backlog.Init()
+	return nil
+}
+
+//SetMaxAge sets the maximum age of messages delivered for the subscription.
+//Messages that waited in the backlog longer than maxAge are dropped and counted by Dropped.
+//Zero disables the limit.
+func (s *Subscription) SetMaxAge(maxAge time.Duration) {
+	s.maxAge = maxAge
+}
+
+//Dropped returns the number of messages dropped because they exceeded the maximum age.
+func (s *Subscription) Dropped() uint64 {
+	return s.stats.Dropped
+}
+
+//Stats returns a snapshot of the delivery statistics of the subscription.
+func (s *Subscription) Stats() SubscriptionStats {
+	return s.stats
+}
+
+//Pause temporarily stops the flow of published messages for the subscription
+//by unsubscribing from the pubsubsql server.
+//Messages already received by the Client are still delivered.
+func (s *Subscription) Pause() error {
+	s.client.pmu.Lock()
+	defer s.client.pmu.Unlock()
+	if s.paused {
+		return nil
+	}
+	err := s.unsubscribe()
+	if err != nil {
+		return err
+	}
+	s.paused = true
+	return nil
+}
+
+// unsubscribe removes the subscription from the pubsubsql server
+func (s *Subscription) unsubscribe() error {
+	c := s.client
+	err := c.execute(fmt.Sprintf("unsubscribe from %s where pubsubid = %s", s.table, s.pubsubId))
+	if err != nil {
+		return err
+	}
+	delete(c.subscriptions, s.pubsubId)
+	return nil
+}
+
+//Resume restarts the flow of published messages for a paused subscription
+//by issuing the subscribe command again.
+//Unless the command uses skip, the pubsubsql server delivers the current rows again as a new snapshot.
+func (s *Subscription) Resume() error {
+	s.client.pmu.Lock()
+	defer s.client.pmu.Unlock()
+	return s.resume()
+}
+
+func (s *Subscription) resume() error {
+	if !s.paused {
+		return nil
+	}
+	return s.subscribe()
+}
+
+// subscribe executes the subscribe command again for the subscription
+func (s *Subscription) subscribe() error {
+	c := s.client
+	err := c.execute(s.command)
+	if err != nil {
+		return err
+	}
+	if c.response.PubSubId == "" {
+		return fmt.Errorf("%w missing pubsubid", ErrProtocol)
+	}
+	s.pubsubId = c.response.PubSubId
+	s.paused = false
+	s.heard = _NOW()
+	s.stale = false
+	c.addSubscription(s)
+	return nil
+}
+
+//SnapshotMode selects whether a new subscription first receives the rows currently in the table.
+type SnapshotMode int
+
+const (
+	//WithSnapshot delivers the rows currently in the table as add actions before live changes.
+	WithSnapshot SnapshotMode = iota
+	//SkipSnapshot delivers only changes made after the subscription was created.
+	SkipSnapshot
+)
+
+//SubscribeOptions configures a subscription created with Subscribe.
+type SubscribeOptions struct {
+	//Snapshot selects the initial snapshot behavior, WithSnapshot by default.
+	Snapshot SnapshotMode
+	//Where is an optional filter without the where keyword, for example "ticker = IBM".
+	Where string
+	//Columns limits the delivered columns, all columns are delivered when empty.
+	Columns []string
+}
+
+//Subscribe subscribes the Client to the table and returns the new Subscription.
+//opts may be nil to subscribe to all rows with the initial snapshot.
+func (c *Client) Subscribe(table string, opts *SubscribeOptions) (*Subscription, error) {
+	command, err := subscribeCommand(table, opts)
+	if err != nil {
+		return nil, err
+	}
+	err = c.Execute(command)
+	if err != nil {
+		return nil, err
+	}
+	s := c.Subscription(c.response.PubSubId)
+	if s == nil {
+		return nil, fmt.Errorf("%w missing pubsubid", ErrProtocol)
+	}
+	return s, nil
+}
+
+//SubscribeMany subscribes the Client to every table with the same options.
+//Published messages of all subscriptions are received with WaitForPubSub and
+//tagged with their source table by PubSubTable.
+//When a subscription fails the subscriptions already created are removed.
+func (c *Client) SubscribeMany(tables []string, opts *SubscribeOptions) ([]*Subscription, error) {
+	subscriptions := make([]*Subscription, 0, len(tables))
+	for _, table := range tables {
+		s, err := c.Subscribe(table, opts)
+		if err != nil {
+			for _, created := range subscriptions {
+				created.unsubscribe()
+			}
+			return nil, err
+		}
+		subscriptions = append(subscriptions, s)
+	}
+	return subscriptions, nil
+}
+
+// subscribeCommand composes and validates the subscribe command
+func subscribeCommand(table string, opts *SubscribeOptions) (string, error) {
+	if !isIdentifier(table) {
+		return "", fmt.Errorf("Invalid table name: %q", table)
+	}
+	if opts == nil {
+		opts = &SubscribeOptions{}
+	}
+	columns := "*"
+	if len(opts.Columns) > 0 {
+		for _, column := range opts.Columns {
+			if !isIdentifier(column) {
+				return "", fmt.Errorf("Invalid column name: %q", column)
+			}
+		}
+		columns = strings.Join(opts.Columns, ", ")
+	}
+	var command string
+	switch opts.Snapshot {
+	case WithSnapshot:
+		command = "subscribe " + columns + " from " + table
+	case SkipSnapshot:
+		command = "subscribe skip " + columns + " from " + table
+	default:
+		return "", fmt.Errorf("Invalid snapshot mode: %d", opts.Snapshot)
+	}
+	if where := strings.TrimSpace(opts.Where); where != "" {
+		if verb, _ := firstWord(where); verb == "where" {
+			return "", errors.New("Where filter must not include the where keyword")
+		}
+		command += " where " + where
+	}
+	return command, nil
+}
+
+//Subscription returns the active subscription with the given PubSubId or nil when there is none.
+func (c *Client) Subscription(pubsubid string) *Subscription {
+	return c.subscriptions[pubsubid]
+}
+
+//Subscriptions returns the active subscriptions of the Client.
+func (c *Client) Subscriptions() []*Subscription {
+	subscriptions := make([]*Subscription, 0, len(c.subscriptions))
+	for _, s := range c.subscriptions {
+		subscriptions = append(subscriptions, s)
+	}
+	return subscriptions
+}
+
+//SubscriptionDescriptor is the serializable state of a Subscription exported by ExportSubscriptions.
+//The pubsubsql server does not number published messages, so no position within the stream is kept;
+//unless the command uses skip the imported subscription receives a new snapshot.
+type SubscriptionDescriptor struct {
+	Command string        `json:"command"`
+	MaxAge  time.Duration `json:"maxAge,omitempty"`
+}
+
+//ExportSubscriptions returns the descriptors of the active subscriptions in the order they were created,
+//to be stored and passed to ImportSubscriptions after an application restart.
+func (c *Client) ExportSubscriptions() []SubscriptionDescriptor {
+	subscriptions := c.sortedSubscriptions()
+	descriptors := make([]SubscriptionDescriptor, len(subscriptions))
+	for i, s := range subscriptions {
+		descriptors[i] = SubscriptionDescriptor{Command: s.command, MaxAge: s.maxAge}
+	}
+	return descriptors
+}
+
+// sortedSubscriptions returns the active subscriptions in the order they were created
+func (c *Client) sortedSubscriptions() []*Subscription {
+	subscriptions := c.Subscriptions()
+	sort.Slice(subscriptions, func(i, j int) bool {
+		a, b := subscriptions[i].pubsubId, subscriptions[j].pubsubId
+		if len(a) != len(b) {
+			return len(a) < len(b)
+		}
+		return a < b
+	})
+	return subscriptions
+}
+
+//ImportSubscriptions re-creates the exported subscriptions on the connected Client.
+//When a subscription fails the subscriptions already created are removed.
+func (c *Client) ImportSubscriptions(descriptors []SubscriptionDescriptor) ([]*Subscription, error) {
+	subscriptions := make([]*Subscription, 0, len(descriptors))
+	for _, descriptor := range descriptors {
+		var s *Subscription
+		err := c.Execute(descriptor.Command)
+		if err == nil {
+			s = c.Subscription(c.response.PubSubId)
+			if s == nil {
+				err = fmt.Errorf("Not a subscribe command: %q", descriptor.Command)
+			}
+		}
+		if err != nil {
+			for _, created := range subscriptions {
+				created.unsubscribe()
+			}
+			return nil, err
+		}
+		s.maxAge = descriptor.MaxAge
+		subscriptions = append(subscriptions, s)
+	}
+	return subscriptions, nil
+}
+
+// matches the pubsubid filter of an unsubscribe command
+var _PUBSUBID_FILTER = regexp.MustCompile(`(?i)\bwhere\s+pubsubid\s*=\s*'?([^\s']+)'?`)
+
+// trackSubscriptions records subscriptions created or removed by a successfully executed command
+func (c *Client) trackSubscriptions(command string) {
+	switch c.response.Action {
+	case "subscribe":
+		if c.response.PubSubId == "" {
+			return
+		}
+		table, _, _ := commandTable(command)
+		c.addSubscription(&Subscription{
+			client:   c,
+			command:  command,
+			table:    table,
+			pubsubId: c.response.PubSubId,
+			heard:    _NOW(),
+		})
+	case "unsubscribe":
+		if match := _PUBSUBID_FILTER.FindStringSubmatch(command); match != nil {
+			delete(c.subscriptions, match[1])
+			return
+		}
+		table, _, _ := commandTable(command)
+		for id, s := range c.subscriptions {
+			if table == "" || s.table == table {
+				delete(c.subscriptions, id)
+			}
+		}
+	}
+}
+
+// delivered updates the statistics of the subscription of the current message
+func (c *Client) delivered(received time.Time) {
+	s := c.subscriptions[c.response.PubSubId]
+	if s == nil {
+		return
+	}
+	now := _NOW()
+	s.stats.Delivered++
+	s.stats.Lag = since(received)
+	s.stats.LastMessage = now
+}
+
+func (c *Client) addSubscription(s *Subscription) {
+	if c.subscriptions == nil {
+		c.subscriptions = make(map[string]*Subscription)
+	}
+	c.subscriptions[s.pubsubId] = s
+}
@@ -0,0 +1,233 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"encoding/json"
+	. "gopkg.in/check.v1"
+	"time"
+)
+
+func (s *TestSuite) TestSubscriptionPauseResume(c *C) {
+	client := newPipeClient(
+		`{"status":"ok","action":"subscribe","pubsubid":"1"}`,
+		`{"status":"ok","action":"unsubscribe"}`,
+		`{"status":"ok","action":"subscribe","pubsubid":"2"}`,
+	)
+	c.Assert(client.Execute("subscribe * from stocks"), IsNil)
+	sub := client.Subscription("1")
+	c.Assert(sub, NotNil)
+	c.Assert(sub.Table(), Equals, "stocks")
+
+	c.Assert(sub.Pause(), IsNil)
+	c.Assert(sub.Paused(), Equals, true)
+	c.Assert(client.Subscriptions(), HasLen, 0)
+
+	c.Assert(sub.Resume(), IsNil)
+	c.Assert(sub.Paused(), Equals, false)
+	c.Assert(sub.PubSubId(), Equals, "2")
+	c.Assert(client.Subscription("2"), Equals, sub)
+}
+
+func (s *TestSuite) TestSubscriptionResumeAfterReconnect(c *C) {
+	client := newPipeClient(
+		`{"status":"ok","action":"subscribe","pubsubid":"1"}`,
+		`{"status":"ok","action":"unsubscribe"}`,
+	)
+	c.Assert(client.Execute("subscribe * from stocks"), IsNil)
+	sub := client.Subscription("1")
+	c.Assert(sub.Pause(), IsNil)
+	client.Disconnect()
+
+	reconnected := newPipeClient(`{"status":"ok","action":"subscribe","pubsubid":"7"}`)
+	client.rw = reconnected.rw
+	c.Assert(sub.Resume(), IsNil)
+	c.Assert(client.Subscription("7"), Equals, sub)
+}
+
+func (s *TestSuite) TestUnsubscribeByPubSubId(c *C) {
+	client := newPipeClient(
+		`{"status":"ok","action":"subscribe","pubsubid":"1"}`,
+		`{"status":"ok","action":"subscribe","pubsubid":"2"}`,
+		`{"status":"ok","action":"unsubscribe"}`,
+		`{"status":"ok","action":"unsubscribe"}`,
+	)
+	c.Assert(client.Execute("subscribe * from stocks where ticker = IBM"), IsNil)
+	c.Assert(client.Execute("subscribe * from stocks where ticker = MSFT"), IsNil)
+	c.Assert(client.Execute("unsubscribe from stocks where pubsubid = 1"), IsNil)
+	c.Assert(client.Subscription("1"), IsNil)
+	c.Assert(client.Subscription("2"), NotNil)
+	c.Assert(client.Execute("unsubscribe from stocks"), IsNil)
+	c.Assert(client.Subscriptions(), HasLen, 0)
+}
+
+func (s *TestSuite) TestSubscriptionMaxAge(c *C) {
+	client := newPipeClient(
+		`{"status":"ok","action":"subscribe","pubsubid":"1"}`,
+		`pubsub:{"status":"ok","action":"add","pubsubid":"1","rows":1,"fromrow":1,"torow":1,"columns":["id"],"data":[["1"]]}`,
+		`{"status":"ok","action":"status"}`,
+	)
+	c.Assert(client.Execute("subscribe * from stocks"), IsNil)
+	sub := client.Subscription("1")
+	sub.SetMaxAge(time.Millisecond)
+	// the published message lands in the backlog while waiting for the status response
+	c.Assert(client.Execute("status"), IsNil)
+	time.Sleep(5 * time.Millisecond)
+	c.Assert(client.WaitForPubSub(10), NotNil)
+	c.Assert(sub.Dropped(), Equals, uint64(1))
+}
+
+func (s *TestSuite) TestSubscribeCommand(c *C) {
+	command, err := subscribeCommand("stocks", nil)
+	c.Assert(err, IsNil)
+	c.Assert(command, Equals, "subscribe * from stocks")
+
+	command, err = subscribeCommand("stocks", &SubscribeOptions{Snapshot: SkipSnapshot, Where: "ticker = IBM"})
+	c.Assert(err, IsNil)
+	c.Assert(command, Equals, "subscribe skip * from stocks where ticker = IBM")
+
+	command, err = subscribeCommand("stocks", &SubscribeOptions{Snapshot: SkipSnapshot, Columns: []string{"ticker", "bid"}})
+	c.Assert(err, IsNil)
+	c.Assert(command, Equals, "subscribe skip ticker, bid from stocks")
+	_, err = subscribeCommand("stocks", &SubscribeOptions{Columns: []string{"ticker,bid"}})
+	c.Assert(err, NotNil)
+
+	_, err = subscribeCommand("stocks where", nil)
+	c.Assert(err, NotNil)
+	_, err = subscribeCommand("", nil)
+	c.Assert(err, NotNil)
+	_, err = subscribeCommand("stocks", &SubscribeOptions{Snapshot: SnapshotMode(5)})
+	c.Assert(err, NotNil)
+	_, err = subscribeCommand("stocks", &SubscribeOptions{Where: "where ticker = IBM"})
+	c.Assert(err, NotNil)
+}
+
+func (s *TestSuite) TestSubscribe(c *C) {
+	client := newPipeClient(`{"status":"ok","action":"subscribe","pubsubid":"3"}`)
+	sub, err := client.Subscribe("stocks", &SubscribeOptions{Snapshot: SkipSnapshot})
+	c.Assert(err, IsNil)
+	c.Assert(sub.PubSubId(), Equals, "3")
+	c.Assert(sub.Command(), Equals, "subscribe skip * from stocks")
+}
+
+func (s *TestSuite) TestSubscribeMany(c *C) {
+	client := newPipeClient(
+		`{"status":"ok","action":"subscribe","pubsubid":"1"}`,
+		`{"status":"ok","action":"subscribe","pubsubid":"2"}`,
+	)
+	subs, err := client.SubscribeMany([]string{"stocks", "orders"}, nil)
+	c.Assert(err, IsNil)
+	c.Assert(subs, HasLen, 2)
+	c.Assert(client.Subscription("2").Table(), Equals, "orders")
+}
+
+func (s *TestSuite) TestSubscribeManyRollback(c *C) {
+	client := newPipeClient(
+		`{"status":"ok","action":"subscribe","pubsubid":"1"}`,
+		`{"status":"err","msg":"table does not exist"}`,
+		`{"status":"ok","action":"unsubscribe"}`,
+	)
+	_, err := client.SubscribeMany([]string{"stocks", "missing"}, nil)
+	c.Assert(err, NotNil)
+	c.Assert(client.Subscriptions(), HasLen, 0)
+}
+
+func (s *TestSuite) TestPubSubTable(c *C) {
+	client := newPipeClient(
+		`{"status":"ok","action":"subscribe","pubsubid":"1"}`,
+		`{"status":"ok","action":"subscribe","pubsubid":"2"}`,
+		`pubsub:{"status":"ok","action":"add","pubsubid":"2","rows":1,"fromrow":1,"torow":1,"columns":["id"],"data":[["1"]]}`,
+	)
+	_, err := client.SubscribeMany([]string{"stocks", "orders"}, nil)
+	c.Assert(err, IsNil)
+	c.Assert(client.WaitForPubSub(1000), IsNil)
+	c.Assert(client.PubSubTable(), Equals, "orders")
+}
+
+func (s *TestSuite) TestSubscriptionStats(c *C) {
+	client := newPipeClient(
+		`{"status":"ok","action":"subscribe","pubsubid":"1"}`,
+		`pubsub:{"status":"ok","action":"add","pubsubid":"1"}`,
+		`pubsub:{"status":"ok","action":"add","pubsubid":"1"}`,
+	)
+	sub, err := client.Subscribe("stocks", nil)
+	c.Assert(err, IsNil)
+	c.Assert(client.WaitForPubSub(1000), IsNil)
+	c.Assert(client.WaitForPubSub(1000), IsNil)
+	stats := sub.Stats()
+	c.Assert(stats.Delivered, Equals, uint64(2))
+	c.Assert(stats.Dropped, Equals, uint64(0))
+	c.Assert(stats.LastMessage.IsZero(), Equals, false)
+}
+
+func (s *TestSuite) TestExportImportSubscriptions(c *C) {
+	client := newPipeClient(
+		`{"status":"ok","action":"subscribe","pubsubid":"9"}`,
+		`{"status":"ok","action":"subscribe","pubsubid":"10"}`,
+		`{"status":"ok","action":"subscribe","pubsubid":"1"}`,
+		`{"status":"ok","action":"subscribe","pubsubid":"2"}`,
+		`{"status":"ok","action":"subscribe","pubsubid":"3"}`,
+		`{"status":"ok","action":"status"}`,
+		`{"status":"ok","action":"unsubscribe"}`,
+	)
+	c.Assert(client.Execute("subscribe * from stocks"), IsNil)
+	c.Assert(client.Execute("subscribe skip * from orders"), IsNil)
+	client.Subscription("10").SetMaxAge(time.Second)
+	descriptors := client.ExportSubscriptions()
+	c.Assert(descriptors, DeepEquals, []SubscriptionDescriptor{
+		{Command: "subscribe * from stocks"},
+		{Command: "subscribe skip * from orders", MaxAge: time.Second},
+	})
+	data, err := json.Marshal(descriptors)
+	c.Assert(err, IsNil)
+	var restored []SubscriptionDescriptor
+	c.Assert(json.Unmarshal(data, &restored), IsNil)
+
+	subscriptions, err := client.ImportSubscriptions(restored)
+	c.Assert(err, IsNil)
+	c.Assert(subscriptions, HasLen, 2)
+	c.Assert(subscriptions[1].PubSubId(), Equals, "2")
+	c.Assert(subscriptions[1].maxAge, Equals, time.Second)
+
+	_, err = client.ImportSubscriptions([]SubscriptionDescriptor{{Command: "subscribe * from trades"}, {Command: "status"}})
+	c.Assert(err, ErrorMatches, "Not a subscribe command.*")
+	c.Assert(client.Subscription("3"), IsNil)
+}
+
+func (s *TestSuite) TestSubscriptionNext(c *C) {
+	client := newPipeClient(
+		`{"status":"ok","action":"subscribe","pubsubid":"1"}`,
+		`{"status":"ok","action":"subscribe","pubsubid":"2"}`,
+		`pubsub:{"status":"ok","action":"add","pubsubid":"2","rows":1,"fromrow":1,"torow":1,"columns":["id"],"data":[["7"]]}`,
+		`pubsub:{"status":"ok","action":"add","pubsubid":"1","rows":1,"fromrow":1,"torow":1,"columns":["id"],"data":[["3"]]}`,
+		`{"status":"ok","action":"status"}`,
+		`{"status":"ok","action":"unsubscribe"}`,
+	)
+	stocks, err := client.Subscribe("stocks", nil)
+	c.Assert(err, IsNil)
+	orders, err := client.Subscribe("orders", nil)
+	c.Assert(err, IsNil)
+	// the published messages land in the backlog of the Client while waiting for the status response
+	c.Assert(client.Execute("status"), IsNil)
+
+	message, err := stocks.Next(1000)
+	c.Assert(err, IsNil)
+	c.Assert(message.Table, Equals, "stocks")
+	c.Assert(message.Value(0, "id"), Equals, "3")
+	c.Assert(orders.Backlog(), Equals, 1)
+
+	c.Assert(orders.Unsubscribe(), IsNil)
+	c.Assert(orders.Active(), Equals, false)
+	c.Assert(orders.Backlog(), Equals, 0)
+	c.Assert(stocks.Active(), Equals, true)
+	c.Assert(client.Subscriptions(), HasLen, 1)
+}
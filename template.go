@@ -0,0 +1,93 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// compiled templates beyond this number are not cached
+var _MAX_CACHED_TEMPLATES int64 = 1024
+
+// compiled insert templates by table and columns
+var _TEMPLATES struct {
+	count     int64
+	templates sync.Map
+}
+
+//InsertTemplate is the compiled form of the insert commands for a table and columns.
+//The table and columns are validated once, commands for rows only add the values.
+//An InsertTemplate is safe for use by multiple goroutines.
+type InsertTemplate struct {
+	columns int
+	// insert into table (columns) values (
+	prefix string
+}
+
+//CompileInsert validates the table and columns and returns the template of their insert commands.
+//Compiled templates are cached by table and columns, so repeated calls with the same structure
+//return the same template without validating again.
+func CompileInsert(table string, columns []string) (*InsertTemplate, error) {
+	key := table + "\x00" + strings.Join(columns, "\x00")
+	if t, ok := _TEMPLATES.templates.Load(key); ok {
+		return t.(*InsertTemplate), nil
+	}
+	if !isIdentifier(table) {
+		return nil, fmt.Errorf("Invalid table name: %q", table)
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("No columns to insert into %s", table)
+	}
+	for _, column := range columns {
+		if !isIdentifier(column) {
+			return nil, fmt.Errorf("Invalid column name: %q", column)
+		}
+	}
+	t := &InsertTemplate{
+		columns: len(columns),
+		prefix:  "insert into " + table + " (" + strings.Join(columns, ",") + ") values (",
+	}
+	if atomic.AddInt64(&_TEMPLATES.count, 1) <= _MAX_CACHED_TEMPLATES {
+		_TEMPLATES.templates.Store(key, t)
+	}
+	return t, nil
+}
+
+//Command composes the insert command of the row, whose values are in the order of the compiled columns.
+func (t *InsertTemplate) Command(row []string) (string, error) {
+	if len(row) != t.columns {
+		return "", fmt.Errorf("Row has %d values for %d columns", len(row), t.columns)
+	}
+	buffer := _BUILDERS.get()
+	defer _BUILDERS.put(buffer)
+	t.compose(buffer, row)
+	if buffer.Len() > _MAX_COMMAND_SIZE {
+		return "", &CommandTooLargeError{Size: buffer.Len(), Limit: _MAX_COMMAND_SIZE}
+	}
+	return buffer.String(), nil
+}
+
+// compose writes the insert command of the row into the empty buffer
+func (t *InsertTemplate) compose(buffer *bytes.Buffer, row []string) {
+	buffer.WriteString(t.prefix)
+	for i, value := range row {
+		if i > 0 {
+			buffer.WriteString(",")
+		}
+		buffer.WriteString(quoteValue(value))
+	}
+	buffer.WriteString(")")
+}
@@ -0,0 +1,39 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+func (s *TestSuite) TestCompileInsert(c *C) {
+	t, err := CompileInsert("stocks", []string{"ticker", "bid"})
+	c.Assert(err, IsNil)
+	command, err := t.Command([]string{"IBM", "12 3"})
+	c.Assert(err, IsNil)
+	c.Assert(command, Equals, "insert into stocks (ticker,bid) values (IBM,'12 3')")
+	_, err = t.Command([]string{"IBM"})
+	c.Assert(err, ErrorMatches, "Row has 1 values for 2 columns")
+
+	// the same structure returns the cached template
+	cached, err := CompileInsert("stocks", []string{"ticker", "bid"})
+	c.Assert(err, IsNil)
+	c.Assert(cached, Equals, t)
+	other, err := CompileInsert("stocks", []string{"ticker"})
+	c.Assert(err, IsNil)
+	c.Assert(other == t, Equals, false)
+
+	_, err = CompileInsert("stocks", []string{"ticker,bid"})
+	c.Assert(err, ErrorMatches, "Invalid column name.*")
+	_, err = CompileInsert("stocks", nil)
+	c.Assert(err, NotNil)
+}
@@ -0,0 +1,78 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"time"
+)
+
+//SetTLSConfig makes Connect encrypt the connections to the pubsubsql server with TLS,
+//for servers behind a TLS terminator. Client certificates are set in cfg.Certificates.
+//When cfg.ServerName is empty, the host of the address passed to Connect is sent for SNI
+//and used to verify the certificate of the server. A nil cfg restores plain TCP connections.
+func (c *Client) SetTLSConfig(cfg *tls.Config) {
+	c.tlsConfig = cfg
+}
+
+//ConnectTLS connects the Client to the pubsubsql server over TLS with cfg as described by SetTLSConfig.
+//Later calls to Connect use TLS as well.
+func (c *Client) ConnectTLS(address string, cfg *tls.Config) error {
+	c.SetTLSConfig(cfg)
+	return c.Connect(address)
+}
+
+// dial opens a connection to the resolved address, with TLS when configured
+func (c *Client) dial(ctx context.Context, address string) (net.Conn, error) {
+	timeout := c.connectTimeout
+	if timeout == 0 {
+		timeout = _DEFAULT_CONNECT_TIMEOUT
+	}
+	if c.dialer != nil {
+		return c.dialCustom(ctx, address, timeout)
+	}
+	dialer := &net.Dialer{Timeout: timeout}
+	if c.tlsConfig == nil {
+		return dialer.DialContext(ctx, "tcp", address)
+	}
+	tlsDialer := tls.Dialer{NetDialer: dialer, Config: c.serverConfig()}
+	return tlsDialer.DialContext(ctx, "tcp", address)
+}
+
+// dialCustom opens a connection with the Dialer of the Client and performs the TLS handshake over it
+func (c *Client) dialCustom(ctx context.Context, address string, timeout time.Duration) (net.Conn, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	conn, err := c.dialer(ctx, "tcp", address)
+	if err != nil || c.tlsConfig == nil {
+		return conn, err
+	}
+	tlsConn := tls.Client(conn, c.serverConfig())
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+// serverConfig returns the TLS configuration naming the server
+func (c *Client) serverConfig() *tls.Config {
+	cfg := c.tlsConfig
+	// the resolved address may be an ip address, the server is known by the original host
+	if host, _, err := net.SplitHostPort(c.address); err == nil && cfg.ServerName == "" {
+		cfg = cfg.Clone()
+		cfg.ServerName = host
+	}
+	return cfg
+}
@@ -0,0 +1,66 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	. "gopkg.in/check.v1"
+	"net/http/httptest"
+)
+
+func (s *TestSuite) TestConnectTLS(c *C) {
+	// borrow the test certificate of httptest
+	https := httptest.NewTLSServer(nil)
+	certificates := https.TLS.Certificates
+	roots := x509.NewCertPool()
+	roots.AddCert(https.Certificate())
+	https.Close()
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: certificates})
+	c.Assert(err, IsNil)
+	defer listener.Close()
+	serverNames := make(chan string, 1)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			tlsConn := conn.(*tls.Conn)
+			if tlsConn.Handshake() != nil {
+				conn.Close()
+				continue
+			}
+			serverNames <- tlsConn.ConnectionState().ServerName
+			header, _, err := ReadFrame(conn)
+			if err == nil {
+				WriteFrame(conn, header.RequestId, []byte(`{"status":"ok","action":"status"}`))
+			}
+			conn.Close()
+		}
+	}()
+
+	// the certificate is also valid for example.com, sent for SNI rather than the resolved address
+	client := new(Client)
+	client.SetResolver(func(address string) (string, error) {
+		return listener.Addr().String(), nil
+	})
+	c.Assert(client.ConnectTLS("example.com:7777", &tls.Config{RootCAs: roots}), IsNil)
+	c.Assert(<-serverNames, Equals, "example.com")
+	c.Assert(client.Execute("status"), IsNil)
+	client.Disconnect()
+
+	// the certificate is verified
+	client.SetTLSConfig(&tls.Config{})
+	c.Assert(client.Connect(listener.Addr().String()), ErrorMatches, ".*certificate.*")
+}
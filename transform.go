@@ -0,0 +1,102 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"fmt"
+)
+
+//Transform rewrites a published message before it is delivered, for example to rename columns,
+//derive computed columns or convert units. It may change the columns and values in place
+//but must keep the number of rows; use SetRowFilter to withhold rows.
+type Transform func(message *Message) error
+
+//Transform appends transforms run in order on every message published for the subscription
+//before WaitForPubSub, Next or the channel of SubscribeChan deliver it.
+//A failing transform makes the delivering call return its error.
+func (s *Subscription) Transform(transforms ...Transform) {
+	s.transforms = append(s.transforms, transforms...)
+}
+
+//RenameColumn returns a Transform renaming the column, messages without the column are unchanged.
+func RenameColumn(from string, to string) Transform {
+	return func(message *Message) error {
+		for i, column := range message.Columns {
+			if column == from {
+				message.Columns[i] = to
+			}
+		}
+		return nil
+	}
+}
+
+//DeriveColumn returns a Transform adding the column with the value computed from every row.
+func DeriveColumn(column string, derive func(value func(column string) string) string) Transform {
+	return func(message *Message) error {
+		for row := range message.Rows {
+			value := derive(func(column string) string { return message.Value(row, column) })
+			message.Rows[row] = append(message.Rows[row], value)
+		}
+		message.Columns = append(message.Columns, column)
+		return nil
+	}
+}
+
+//ConvertColumn returns a Transform replacing every value of the column with its conversion,
+//messages without the column are unchanged.
+func ConvertColumn(column string, convert func(value string) (string, error)) Transform {
+	return func(message *Message) error {
+		for ordinal, name := range message.Columns {
+			if name != column {
+				continue
+			}
+			for _, row := range message.Rows {
+				if ordinal >= len(row) {
+					continue
+				}
+				value, err := convert(row[ordinal])
+				if err != nil {
+					return fmt.Errorf("Cannot convert %q of column %s: %w", row[ordinal], column, err)
+				}
+				row[ordinal] = value
+			}
+		}
+		return nil
+	}
+}
+
+// transform runs the transforms of the subscription on the current published message
+func (c *Client) transform() error {
+	s := c.subscriptions[c.response.PubSubId]
+	if s == nil || len(s.transforms) == 0 {
+		return nil
+	}
+	message := &Message{
+		ResultSet: ResultSet{Action: c.response.Action, Columns: c.response.Columns, Rows: c.response.Data},
+		PubSubId:  s.pubsubId,
+		Table:     s.table,
+	}
+	rows := len(message.Rows)
+	for _, transform := range s.transforms {
+		if err := transform(message); err != nil {
+			return err
+		}
+		if len(message.Rows) != rows {
+			return fmt.Errorf("Transform of subscription %s changed the number of rows", s.pubsubId)
+		}
+	}
+	c.response.Action = message.Action
+	c.response.Columns = message.Columns
+	c.response.Data = message.Rows
+	c.setColumns()
+	return nil
+}
@@ -0,0 +1,48 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"errors"
+	. "gopkg.in/check.v1"
+	"strconv"
+)
+
+func (s *TestSuite) TestSubscriptionTransform(c *C) {
+	client := newPipeClient(
+		`{"status":"ok","action":"subscribe","pubsubid":"1"}`,
+		`pubsub:{"status":"ok","action":"add","pubsubid":"1","rows":2,"fromrow":1,"torow":2,"columns":["sym","px"],"data":[["IBM","140"],["MSFT","30.5"]]}`,
+		`pubsub:{"status":"ok","action":"add","pubsubid":"1","rows":1,"fromrow":1,"torow":1,"columns":["sym","px"],"data":[["BAD","n/a"]]}`,
+	)
+	sub, err := client.Subscribe("stocks", nil)
+	c.Assert(err, IsNil)
+	cents := func(value string) (string, error) {
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return "", errors.New("not a price")
+		}
+		return strconv.Itoa(int(f * 100)), nil
+	}
+	sub.Transform(
+		RenameColumn("sym", "ticker"),
+		ConvertColumn("px", cents),
+		DeriveColumn("label", func(value func(string) string) string { return value("ticker") + "@" + value("px") }),
+	)
+
+	message, err := sub.Next(1000)
+	c.Assert(err, IsNil)
+	c.Assert(message.Columns, DeepEquals, []string{"ticker", "px", "label"})
+	c.Assert(message.Rows, DeepEquals, [][]string{{"IBM", "14000", "IBM@14000"}, {"MSFT", "3050", "MSFT@3050"}})
+
+	err = client.WaitForPubSub(1000)
+	c.Assert(err, ErrorMatches, `Cannot convert "n/a" of column px: not a price`)
+}
@@ -0,0 +1,126 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+//ValueError is returned by the typed value accessors when a column is missing
+//or its value cannot be converted.
+type ValueError struct {
+	Column string
+	Value  string
+	//Type is the requested type, such as int or time.
+	Type string
+	//Err is the conversion error, nil when the column does not exist.
+	Err error
+}
+
+func (e *ValueError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("Column %s does not exist", e.Column)
+	}
+	return fmt.Sprintf("Value %q of column %s is not a valid %s: %v", e.Value, e.Column, e.Type, e.Err)
+}
+
+func (e *ValueError) Unwrap() error {
+	return e.Err
+}
+
+//ValueInt returns the value of the column within the current row as a base 10 integer.
+func (c *Client) ValueInt(column string) (int64, error) {
+	value, err := c.typedValue(column, "int")
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, &ValueError{Column: column, Value: value, Type: "int", Err: err}
+	}
+	return n, nil
+}
+
+//ValueFloat returns the value of the column within the current row as a floating point number.
+func (c *Client) ValueFloat(column string) (float64, error) {
+	value, err := c.typedValue(column, "float")
+	if err != nil {
+		return 0, err
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, &ValueError{Column: column, Value: value, Type: "float", Err: err}
+	}
+	return f, nil
+}
+
+//ValueBool returns the value of the column within the current row as a boolean,
+//accepting the values understood by strconv.ParseBool such as true, false, 1 and 0.
+func (c *Client) ValueBool(column string) (bool, error) {
+	value, err := c.typedValue(column, "bool")
+	if err != nil {
+		return false, err
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, &ValueError{Column: column, Value: value, Type: "bool", Err: err}
+	}
+	return b, nil
+}
+
+//ValueTime returns the value of the column within the current row parsed with the layout of time.Parse.
+func (c *Client) ValueTime(column string, layout string) (time.Time, error) {
+	value, err := c.typedValue(column, "time")
+	if err != nil {
+		return time.Time{}, err
+	}
+	t, err := time.Parse(layout, value)
+	if err != nil {
+		return time.Time{}, &ValueError{Column: column, Value: value, Type: "time", Err: err}
+	}
+	return t, nil
+}
+
+// typedValue returns the value of the column or an error when the column does not exist
+func (c *Client) typedValue(column string, kind string) (string, error) {
+	if !c.HasColumn(column) {
+		return "", &ValueError{Column: column, Type: kind}
+	}
+	return c.Value(column), nil
+}
+
+//RowMap returns the values of the current row keyed by column name.
+func (c *Client) RowMap() map[string]string {
+	row := make(map[string]string, len(c.response.Columns))
+	for ordinal, column := range c.response.Columns {
+		row[column] = c.ValueByOrdinal(ordinal)
+	}
+	return row
+}
+
+//AllRowsMaps moves through the remaining rows of the result set with NextRow
+//and returns each row as returned by RowMap.
+func (c *Client) AllRowsMaps() ([]map[string]string, error) {
+	var rows []map[string]string
+	for {
+		ok, err := c.NextRow()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return rows, nil
+		}
+		rows = append(rows, c.RowMap())
+	}
+}
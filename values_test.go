@@ -0,0 +1,59 @@
+/* Copyright (C) 2014 CompleteDB LLC.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the Apache License Version 2.0 http://www.apache.org/licenses.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ *
+ */
+
+package pubsubsql
+
+import (
+	"errors"
+	. "gopkg.in/check.v1"
+	"strconv"
+	"time"
+)
+
+func (s *TestSuite) TestTypedValues(c *C) {
+	client := newPipeClient(`{"status":"ok","action":"select","rows":1,"fromrow":1,"torow":1,"columns":["qty","bid","open","at","ticker"],"data":[["12","140.5","true","2014-03-01T10:00:00Z","IBM"]]}`)
+	c.Assert(client.Execute("select * from stocks"), IsNil)
+	ok, err := client.NextRow()
+	c.Assert(err, IsNil)
+	c.Assert(ok, Equals, true)
+
+	qty, err := client.ValueInt("qty")
+	c.Assert(err, IsNil)
+	c.Assert(qty, Equals, int64(12))
+	bid, err := client.ValueFloat("bid")
+	c.Assert(err, IsNil)
+	c.Assert(bid, Equals, 140.5)
+	open, err := client.ValueBool("open")
+	c.Assert(err, IsNil)
+	c.Assert(open, Equals, true)
+	at, err := client.ValueTime("at", time.RFC3339)
+	c.Assert(err, IsNil)
+	c.Assert(at.Equal(time.Date(2014, 3, 1, 10, 0, 0, 0, time.UTC)), Equals, true)
+
+	_, err = client.ValueInt("ticker")
+	c.Assert(err, ErrorMatches, `Value "IBM" of column ticker is not a valid int: .*`)
+	c.Assert(errors.Is(err, strconv.ErrSyntax), Equals, true)
+	_, err = client.ValueFloat("missing")
+	c.Assert(err, ErrorMatches, "Column missing does not exist")
+}
+
+func (s *TestSuite) TestRowMaps(c *C) {
+	client := newPipeClient(`{"status":"ok","action":"select","rows":3,"fromrow":1,"torow":3,"columns":["id","ticker"],"data":[["1","IBM"],["2","MSFT"],["3",""]]}`)
+	c.Assert(client.Execute("select * from stocks"), IsNil)
+	ok, err := client.NextRow()
+	c.Assert(err, IsNil)
+	c.Assert(ok, Equals, true)
+	c.Assert(client.RowMap(), DeepEquals, map[string]string{"id": "1", "ticker": "IBM"})
+
+	rows, err := client.AllRowsMaps()
+	c.Assert(err, IsNil)
+	c.Assert(rows, DeepEquals, []map[string]string{{"id": "2", "ticker": "MSFT"}, {"id": "3", "ticker": ""}})
+}